@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var entityRe = regexp.MustCompile(`^&#?[a-zA-Z0-9]+;`)
+
+// autolinkRule regex -> url (.mkup.toml), a group named "link"
+// limits the linked text (go regexp has no lookbehind)
+//
+//	[[autolinks]]
+//	pattern = 'PROJ-\d+'
+//	url = "https://tracker.example.com/browse/$0"
+//
+//	[[autolinks]]
+//	pattern = '(?:^|\s)(?P<link>#(\d+))\b'
+//	url = "https://github.com/org/repo/issues/$2"
+type autolinkRule struct {
+	Pattern string `toml:"pattern"`
+	URL     string `toml:"url"`
+
+	re   *regexp.Regexp
+	link int
+}
+
+func (al *autolinkRule) compile() (err error) {
+	al.re, err = regexp.Compile(al.Pattern)
+	if err != nil {
+		return fmt.Errorf("autolinks: %v", err)
+	}
+	al.link = 0
+	for i, n := range al.re.SubexpNames() {
+		if n == "link" {
+			al.link = i
+		}
+	}
+	return nil
+}
+
+// Autolink apply autolink rules to html text (already escaped) in one pass
+// from the left: the earliest match wins, on the same position the earlier
+// rule, and the linked text is not matched again
+func Autolink(s string, rules []autolinkRule) string {
+	type link struct {
+		from, to, rule int
+		url            []byte
+	}
+	links := []link{}
+	for i, al := range rules {
+		if al.re == nil {
+			continue
+		}
+		for _, m := range al.re.FindAllStringSubmatchIndex(s, -1) {
+			from, to := m[2*al.link], m[2*al.link+1]
+			if from < 0 || from == to || insideEntity(s, from) {
+				continue
+			}
+			links = append(links, link{from, to, i, al.re.ExpandString(nil, al.URL, s, m)})
+		}
+	}
+	if len(links) == 0 {
+		return s
+	}
+	sort.SliceStable(links, func(i, j int) bool {
+		if links[i].from != links[j].from {
+			return links[i].from < links[j].from
+		}
+		return links[i].rule < links[j].rule
+	})
+
+	var out []byte
+	last := 0
+	for _, l := range links {
+		if l.from < last {
+			continue
+		}
+		out = append(out, s[last:l.from]...)
+		out = append(out, `<a class="autolink" href="`...)
+		out = append(out, html.EscapeString(html.UnescapeString(string(l.url)))...)
+		out = append(out, `">`...)
+		out = append(out, s[l.from:l.to]...)
+		out = append(out, "</a>"...)
+		last = l.to
+	}
+	return string(append(out, s[last:]...))
+}
+
+// insideEntity i is within an html entity (&#39; etc.)
+func insideEntity(s string, i int) bool {
+	j := strings.LastIndex(s[:i], "&")
+	if j < 0 || i-j > 10 || strings.Contains(s[j:i], ";") {
+		return false
+	}
+	return entityRe.MatchString(s[j:])
+}
@@ -0,0 +1,59 @@
+package main
+
+import "testing"
+
+func autolinkRules(t *testing.T, rules ...string) []autolinkRule {
+	t.Helper()
+	als := []autolinkRule{}
+	for i := 0; i < len(rules); i += 2 {
+		al := autolinkRule{Pattern: rules[i], URL: rules[i+1]}
+		if err := al.compile(); err != nil {
+			t.Fatal(err)
+		}
+		als = append(als, al)
+	}
+	return als
+}
+
+func TestAutolink(t *testing.T) {
+	tests := []struct {
+		rules []string
+		s     string
+		want  string
+	}{
+		{nil, "PROJ-1", "PROJ-1"},
+		{[]string{`PROJ-\d+`, "https://t/$0"}, "see PROJ-12 and PROJ-3",
+			`see <a class="autolink" href="https://t/PROJ-12">PROJ-12</a> and <a class="autolink" href="https://t/PROJ-3">PROJ-3</a>`},
+		// 先に始まる方が勝ち, 重なる後の方は捨てる
+		{[]string{`\d+`, "https://n/$0", `PROJ-\d+`, "https://t/$0"}, "PROJ-12 7",
+			`<a class="autolink" href="https://t/PROJ-12">PROJ-12</a> <a class="autolink" href="https://n/7">7</a>`},
+		// 同じ位置なら前のルール
+		{[]string{`abc`, "https://a/", `abcdef`, "https://b/"}, "abcdef",
+			`<a class="autolink" href="https://a/">abc</a>def`},
+		// 付けたリンクの url をもう一度リンクにしない
+		{[]string{`PROJ-\d+`, "https://t/browse/$0", `browse`, "https://b/"}, "PROJ-1 browse",
+			`<a class="autolink" href="https://t/browse/PROJ-1">PROJ-1</a> <a class="autolink" href="https://b/">browse</a>`},
+		{[]string{`(?:^|\s)(?P<link>#(\d+))\b`, "https://i/$2"}, "fix #12, a#3",
+			`fix <a class="autolink" href="https://i/12">#12</a>, a#3`},
+		{[]string{`\d+`, "https://n/$0"}, "it&#39;s 39",
+			`it&#39;s <a class="autolink" href="https://n/39">39</a>`},
+		{[]string{`q=(\w+)`, "https://s/?a=1&b=$1"}, "q=go",
+			`<a class="autolink" href="https://s/?a=1&amp;b=go">q=go</a>`},
+	}
+	for _, tt := range tests {
+		if got := Autolink(tt.s, autolinkRules(t, tt.rules...)); got != tt.want {
+			t.Errorf("Autolink(%q) with %q:\n got %s\nwant %s", tt.s, tt.rules, got, tt.want)
+		}
+	}
+}
+
+func TestAutolinkSkipsLinksAndCode(t *testing.T) {
+	rules := autolinkRules(t, `PROJ-\d+`, "https://t/$0")
+	got := string(MapHTMLText([]byte(`<p><a href="/x">PROJ-1</a> <code>PROJ-2</code> PROJ-3</p>`), func(s string) string {
+		return Autolink(s, rules)
+	}))
+	want := `<p><a href="/x">PROJ-1</a> <code>PROJ-2</code> <a class="autolink" href="https://t/PROJ-3">PROJ-3</a></p>`
+	if got != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+}
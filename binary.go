@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hexRows rows of 16 bytes per page of the hex dump
+const hexRows = 256
+
+// fileHashes digests of a file, cached by path, size and mtime
+type fileHashes struct {
+	MD5, SHA1, SHA256 string
+}
+
+var (
+	hashCacheMu sync.Mutex
+	hashCache   = map[string]fileHashes{}
+)
+
+// SniffFile content type of the head of fp, binary when it has NUL bytes or
+// is not a text type
+func SniffFile(fp string) (ctype string, binary bool) {
+	f, err := os.Open(fp)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+	if n == 0 {
+		return "text/plain; charset=utf-8", false
+	}
+	ctype = http.DetectContentType(head)
+	text := strings.HasPrefix(ctype, "text/") || strings.Contains(ctype, "json") ||
+		strings.Contains(ctype, "xml") || strings.Contains(ctype, "javascript")
+	return ctype, bytes.IndexByte(head, 0) >= 0 || !text
+}
+
+// FileHashes md5, sha1 and sha256 of fp
+func FileHashes(fp string, info os.FileInfo) (fileHashes, error) {
+	key := fmt.Sprintf("%s\x00%d\x00%d", fp, info.Size(), info.ModTime().UnixNano())
+	hashCacheMu.Lock()
+	h, ok := hashCache[key]
+	hashCacheMu.Unlock()
+	if ok {
+		return h, nil
+	}
+
+	f, err := os.Open(fp)
+	if err != nil {
+		return h, err
+	}
+	defer f.Close()
+	m, s1, s256 := md5.New(), sha1.New(), sha256.New()
+	if _, err := io.Copy(io.MultiWriter(m, s1, s256), f); err != nil {
+		return h, err
+	}
+	h = fileHashes{hex.EncodeToString(m.Sum(nil)), hex.EncodeToString(s1.Sum(nil)), hex.EncodeToString(s256.Sum(nil))}
+	hashCacheMu.Lock()
+	hashCache[key] = h
+	hashCacheMu.Unlock()
+	return h, nil
+}
+
+// ByteSize human readable size (1.5 MiB)
+func ByteSize(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	v, unit := float64(n), ""
+	for _, u := range []string{"KiB", "MiB", "GiB", "TiB"} {
+		v, unit = v/1024, u
+		if v < 1024 {
+			break
+		}
+	}
+	return fmt.Sprintf("%.1f %s", v, unit)
+}
+
+// HexDump rows of 16 bytes from offset: offset, hex and ascii columns
+func HexDump(b []byte, offset int64) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(b); i += 16 {
+		row := b[i:]
+		if len(row) > 16 {
+			row = row[:16]
+		}
+		fmt.Fprintf(&buf, "<span class=\"hex-off\">%08x</span>  ", offset+int64(i))
+		for j := 0; j < 16; j++ {
+			if j == 8 {
+				buf.WriteByte(' ')
+			}
+			if j < len(row) {
+				fmt.Fprintf(&buf, "%02x ", row[j])
+			} else {
+				buf.WriteString("   ")
+			}
+		}
+		buf.WriteString(" <span class=\"hex-ascii\">")
+		for _, c := range row {
+			if c < 0x20 || c > 0x7e {
+				c = '.'
+			}
+			buf.WriteString(html.EscapeString(string(c)))
+		}
+		buf.WriteString("</span>\n")
+	}
+	return buf.String()
+}
+
+// binaryview metadata, hex dump and download of a binary file
+func binaryview(cwd, ctype string, w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Path
+	fp := filepath.Join(cwd, name)
+	f, err := os.Open(fp)
+	if err != nil {
+		http.Error(w, "404 page not found", 404)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, "404 page not found", 404)
+		return
+	}
+
+	if r.FormValue("download") != "" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(filepath.Base(name))))
+		w.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeContent(w, r, filepath.Base(name), info.ModTime(), f)
+		return
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("<table class=\"bin-meta\">\n")
+	row := func(k, v string) {
+		fmt.Fprintf(&buf, "<tr><th>%s</th><td>%s</td></tr>\n", k, v)
+	}
+	row("Name", html.EscapeString(filepath.Base(name)))
+	row("Size", fmt.Sprintf("%s (%d bytes)", ByteSize(info.Size()), info.Size()))
+	row("Type", html.EscapeString(ctype))
+	row("Modified", info.ModTime().Format(time.RFC3339))
+	if h, err := FileHashes(fp, info); err != nil {
+		row("Hashes", "<span class=\"meta-error\">"+html.EscapeString(err.Error())+"</span>")
+	} else {
+		row("MD5", "<code>"+h.MD5+"</code>")
+		row("SHA-1", "<code>"+h.SHA1+"</code>")
+		row("SHA-256", "<code>"+h.SHA256+"</code>")
+	}
+	buf.WriteString("</table>\n")
+	fmt.Fprintf(&buf, "<p class=\"data-tools\"><a href=\"%s?download=1\">download</a></p>\n",
+		html.EscapeString((&url.URL{Path: name}).String()))
+
+	// 表示するページ分だけ読む
+	rows := int((info.Size() + 15) / 16)
+	pageNo, _ := strconv.Atoi(r.FormValue("page"))
+	if pageNo < 1 || (pageNo-1)*hexRows >= rows {
+		pageNo = 1
+	}
+	offset := int64(pageNo-1) * hexRows * 16
+	b := make([]byte, hexRows*16)
+	n, err := f.ReadAt(b, offset)
+	if err != nil && err != io.EOF {
+		buf.WriteString(extRenderError("hex", err))
+	}
+	pager := ""
+	if rows > hexRows {
+		pager = dataPager(name, url.Values{}, pageNo, rows, hexRows)
+	}
+	buf.WriteString(pager)
+	buf.WriteString("<pre class=\"hex-dump\"><code>")
+	buf.WriteString(HexDump(b[:n], offset))
+	buf.WriteString("</code></pre>\n")
+	buf.WriteString(pager)
+
+	pg := page{}
+	pg.Title = filepath.Base(name) + " - mkup"
+
+	// 階層メニュー Dirnests
+	rd := filepath.Dir(name)
+	MenuDir(rd, &pg)
+
+	renderPage(w, pg, buf.Bytes())
+	return
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// calloutKind callout style and icon
+type calloutKind struct {
+	Class string
+	Icon  string
+}
+
+// calloutKinds github alerts and obsidian callouts (aliases included)
+var calloutKinds = map[string]calloutKind{
+	"note":      {"note", "&#x2139;&#xFE0F;"},
+	"info":      {"note", "&#x2139;&#xFE0F;"},
+	"todo":      {"note", "&#x2611;&#xFE0F;"},
+	"abstract":  {"abstract", "&#x1F4CB;"},
+	"summary":   {"abstract", "&#x1F4CB;"},
+	"tldr":      {"abstract", "&#x1F4CB;"},
+	"tip":       {"tip", "&#x1F4A1;"},
+	"hint":      {"tip", "&#x1F4A1;"},
+	"success":   {"tip", "&#x2705;"},
+	"check":     {"tip", "&#x2705;"},
+	"done":      {"tip", "&#x2705;"},
+	"important": {"important", "&#x2757;"},
+	"question":  {"important", "&#x2753;"},
+	"help":      {"important", "&#x2753;"},
+	"faq":       {"important", "&#x2753;"},
+	"warning":   {"warning", "&#x26A0;&#xFE0F;"},
+	"attention": {"warning", "&#x26A0;&#xFE0F;"},
+	"caution":   {"caution", "&#x26D4;"},
+	"danger":    {"caution", "&#x26A1;"},
+	"error":     {"caution", "&#x26D4;"},
+	"failure":   {"caution", "&#x274C;"},
+	"fail":      {"caution", "&#x274C;"},
+	"missing":   {"caution", "&#x274C;"},
+	"bug":       {"caution", "&#x1F41B;"},
+	"example":   {"abstract", "&#x1F4DD;"},
+	"quote":     {"quote", "&#x275D;"},
+	"cite":      {"quote", "&#x275D;"},
+}
+
+var (
+	calloutStartRe = regexp.MustCompile(`(?m)^<p>\[![A-Za-z]+\]`)
+	calloutRe      = regexp.MustCompile(`^<p>\[!([A-Za-z]+)\]([+-]?)[ \t]*(.*?)(\n|</p>)`)
+)
+
+// Callouts post process: > [!NOTE] blockquotes to callout boxes
+func Callouts(b []byte) []byte {
+	if !strings.Contains(string(b), "[!") {
+		return b
+	}
+	return []byte(callouts(string(b)))
+}
+
+func callouts(s string) string {
+	const open, close = "<blockquote>", "</blockquote>"
+	var out strings.Builder
+	for {
+		i := strings.Index(s, open)
+		if i < 0 {
+			break
+		}
+		j := closingTag(s, i, open, close)
+		if j < 0 {
+			break
+		}
+		out.WriteString(s[:i])
+		out.WriteString(callout(callouts(s[i+len(open) : j])))
+		s = s[j+len(close):]
+	}
+	out.WriteString(s)
+	return out.String()
+}
+
+// closingTag index of the close tag matching the open tag at i
+func closingTag(s string, i int, open, close string) int {
+	depth := 0
+	for p := i; p < len(s); {
+		o := strings.Index(s[p:], open)
+		c := strings.Index(s[p:], close)
+		if c < 0 {
+			return -1
+		}
+		if o >= 0 && o < c {
+			depth++
+			p += o + len(open)
+			continue
+		}
+		depth--
+		if depth == 0 {
+			return p + c
+		}
+		p += c + len(close)
+	}
+	return -1
+}
+
+// callout blockquote content to callouts
+// (blackfriday は空行で区切られた引用を 1 つにまとめるので marker ごとに分割)
+func callout(inner string) string {
+	locs := calloutStartRe.FindAllStringIndex(inner, -1)
+	if locs == nil {
+		return "<blockquote>" + inner + "</blockquote>"
+	}
+
+	var out strings.Builder
+	if pre := strings.TrimSpace(inner[:locs[0][0]]); pre != "" {
+		out.WriteString("<blockquote>\n" + pre + "\n</blockquote>\n\n")
+	}
+	for k, l := range locs {
+		end := len(inner)
+		if k+1 < len(locs) {
+			end = locs[k+1][0]
+		}
+		if k > 0 {
+			out.WriteString("\n\n")
+		}
+		out.WriteString(calloutBox(inner[l[0]:end]))
+	}
+	return out.String()
+}
+
+func calloutBox(inner string) string {
+	m := calloutRe.FindStringSubmatchIndex(inner)
+	typ := strings.ToLower(inner[m[2]:m[3]])
+	fold := inner[m[4]:m[5]]
+	title := strings.TrimSpace(inner[m[6]:m[7]])
+	body := inner[m[1]:]
+	if inner[m[8]:m[9]] == "\n" {
+		body = "<p>" + body
+	}
+	body = strings.TrimSpace(body)
+	if body == "<p></p>" {
+		body = ""
+	}
+
+	kind, ok := calloutKinds[typ]
+	if !ok {
+		kind = calloutKinds["note"]
+	}
+	if title == "" {
+		title = strings.ToUpper(typ[:1]) + typ[1:]
+	}
+	head := `<span class="callout-icon">` + kind.Icon + `</span> ` + title
+
+	cls := "callout callout-" + kind.Class
+	if fold == "" {
+		return `<div class="` + cls + `">` + "\n" +
+			`<p class="callout-title">` + head + "</p>\n" + body + "\n</div>"
+	}
+	attr := ""
+	if fold == "+" {
+		attr = " open"
+	}
+	return `<details class="` + cls + `"` + attr + ">\n" +
+		`<summary class="callout-title">` + head + "</summary>\n" + body + "\n</details>"
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCallouts(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"> [!NOTE]\n> Body", []string{
+			`<div class="callout callout-note">`,
+			`<p class="callout-title"><span class="callout-icon">&#x2139;&#xFE0F;</span> Note</p>` + "\n<p>Body</p>\n</div>",
+		}},
+		{"> [!warning] Custom *title*\n> text", []string{
+			`<div class="callout callout-warning">`,
+			`</span> Custom <em>title</em></p>`,
+		}},
+		// 別名は同じ色
+		{"> [!danger]\n> x", []string{`<div class="callout callout-caution">`, `</span> Danger</p>`}},
+		{"> [!unknown]\n> x", []string{`<div class="callout callout-note">`, `</span> Unknown</p>`}},
+		{"> [!tip]- Folded\n> x", []string{
+			`<details class="callout callout-tip">`,
+			`<summary class="callout-title"><span class="callout-icon">&#x1F4A1;</span> Folded</summary>`,
+		}},
+		{"> [!tip]+ Open\n> x", []string{`<details class="callout callout-tip" open>`}},
+		{"> [!note]\n> a\n>\n> > [!tip]\n> > inner", []string{
+			`<div class="callout callout-note">`,
+			"<p>a</p>\n\n" + `<div class="callout callout-tip">`,
+			"<p>inner</p>\n</div>\n</div>",
+		}},
+		// 空行で区切った引用は別々の箱
+		{"> [!note]\n> a\n\n> [!tip]\n> b", []string{
+			"<p>a</p>\n</div>\n\n" + `<div class="callout callout-tip">`,
+		}},
+		{"> plain\n> [!note] quote", []string{"<blockquote>\n<p>plain\n[!note] quote</p>\n</blockquote>"}},
+		{"`> [!note]`", []string{"<code>&gt; [!note]</code>"}},
+	}
+	for _, tt := range tests {
+		pg := page{}
+		got := string(RenderMarkdown(t.TempDir(), "/a.md", []byte(tt.src), nil, &pg))
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%q:\n got %s\nwant %s", tt.src, got, want)
+			}
+		}
+	}
+}
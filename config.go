@@ -0,0 +1,75 @@
+package main
+
+import (
+	"log"
+	"os"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+)
+
+// config project settings (.mkup.toml in the served root)
+//
+//	[renderers]
+//	dot = "dot -Tsvg"
+//	plantuml = "java -jar plantuml.jar -pipe -tsvg"
+//
+//	[markdown]
+//	extensions = ["+footnotes", "-autolink"]
+//	html_flags = ["+smartypants"]
+//
+//	[[autolinks]]
+//	pattern = '#(\d+)'
+//	url = "https://github.com/org/repo/issues/$1"
+type config struct {
+	// Renderers fence language -> command (stdin: block, stdout: svg/html),
+	// only used with -renderers
+	Renderers map[string]string `toml:"renderers"`
+
+	// Markdown blackfriday extensions / html flags (see extensions.go)
+	Markdown struct {
+		Extensions []string `toml:"extensions"`
+		HTMLFlags  []string `toml:"html_flags"`
+	} `toml:"markdown"`
+
+	// Autolinks regex -> url rules applied to text
+	Autolinks []autolinkRule `toml:"autolinks"`
+}
+
+var (
+	confMu sync.RWMutex
+	conf   = &config{}
+)
+
+// LoadConfig read config file, missing file is empty config
+func LoadConfig(fn string) error {
+	c := &config{}
+	if _, err := os.Stat(fn); err == nil {
+		if _, err := toml.DecodeFile(fn, c); err != nil {
+			return err
+		}
+		for i := range c.Autolinks {
+			if err := c.Autolinks[i].compile(); err != nil {
+				return err
+			}
+		}
+		// 公開ディレクトリの設定でコマンドを実行させない
+		if len(c.Renderers) > 0 && !*renderers {
+			log.Println("config", fn, "has renderers, ignored without -renderers")
+			c.Renderers = nil
+		}
+		log.Println("config", fn)
+	}
+
+	confMu.Lock()
+	conf = c
+	confMu.Unlock()
+	return nil
+}
+
+// Config current config
+func Config() *config {
+	confMu.RLock()
+	defer confMu.RUnlock()
+	return conf
+}
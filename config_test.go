@@ -0,0 +1,32 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigRenderers(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), ".mkup.toml")
+	if err := ioutil.WriteFile(fn, []byte("[renderers]\ndot = \"dot -Tsvg\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(b bool) { *renderers = b }(*renderers)
+	defer LoadConfig("")
+
+	*renderers = false
+	if err := LoadConfig(fn); err != nil {
+		t.Fatal(err)
+	}
+	if len(Config().Renderers) != 0 {
+		t.Errorf("renderers loaded without -renderers: %v", Config().Renderers)
+	}
+
+	*renderers = true
+	if err := LoadConfig(fn); err != nil {
+		t.Fatal(err)
+	}
+	if Config().Renderers["dot"] != "dot -Tsvg" {
+		t.Errorf("renderers not loaded with -renderers: %v", Config().Renderers)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// csvPageRows rows per page of csvview
+const csvPageRows = 500
+
+// csvReader reader for csv, or tsv when comma is '\t'
+func csvReader(r io.Reader, comma rune) *csv.Reader {
+	cr := csv.NewReader(r)
+	cr.Comma = comma
+	cr.LazyQuotes = true
+	cr.FieldsPerRecord = -1
+	return cr
+}
+
+// csvComma separator by file extension / fence language
+func csvComma(ext string) rune {
+	if strings.ToLower(strings.TrimPrefix(ext, ".")) == "tsv" {
+		return '\t'
+	}
+	return ','
+}
+
+// CSVTable fenced csv / tsv block as data table
+func CSVTable(src []byte, comma rune) string {
+	var buf bytes.Buffer
+	cr := csvReader(bytes.NewReader(src), comma)
+	records, err := cr.ReadAll()
+
+	buf.WriteString("<div class=\"data-table\">\n<input type=\"search\" class=\"data-filter\" placeholder=\"filter\">\n")
+	if len(records) > 0 {
+		writeDataTable(&buf, records[0], records[1:])
+	}
+	if err != nil {
+		buf.WriteString(extRenderError("csv", err))
+	}
+	buf.WriteString("</div>\n")
+	return buf.String()
+}
+
+// writeDataTable header row and body rows as table
+func writeDataTable(buf *bytes.Buffer, header []string, rows [][]string) {
+	cols := len(header)
+	for _, row := range rows {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+
+	buf.WriteString("<table>\n<thead>\n<tr>")
+	for i := 0; i < cols; i++ {
+		th := ""
+		if i < len(header) {
+			th = header[i]
+		}
+		fmt.Fprintf(buf, "<th>%s</th>", html.EscapeString(th))
+	}
+	buf.WriteString("</tr>\n</thead>\n<tbody>\n")
+	for _, row := range rows {
+		buf.WriteString("<tr>")
+		for i := 0; i < cols; i++ {
+			td := ""
+			if i < len(row) {
+				td = row[i]
+			}
+			if _, err := strconv.ParseFloat(strings.TrimSpace(td), 64); err == nil {
+				fmt.Fprintf(buf, "<td class=\"num\">%s</td>", html.EscapeString(td))
+			} else {
+				fmt.Fprintf(buf, "<td>%s</td>", html.EscapeString(td))
+			}
+		}
+		buf.WriteString("</tr>\n")
+	}
+	buf.WriteString("</tbody>\n</table>\n")
+}
+
+// csvview csv / tsv file as table, paged and filtered on the server
+func csvview(cwd string, w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Path
+	f, err := os.Open(filepath.Join(cwd, name))
+	if err != nil {
+		http.Error(w, "404 page not found", 404)
+		return
+	}
+	defer f.Close()
+
+	q := r.FormValue("q")
+	pageNo, _ := strconv.Atoi(r.FormValue("page"))
+	if pageNo < 1 {
+		pageNo = 1
+	}
+	from := (pageNo - 1) * csvPageRows
+
+	// 表示するページの行だけ持つ
+	cr := csvReader(bufio.NewReader(f), csvComma(filepath.Ext(name)))
+	header, err := cr.Read()
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	rows := [][]string{}
+	total := 0
+	lq := strings.ToLower(q)
+	for err == nil {
+		var rec []string
+		rec, err = cr.Read()
+		if err != nil {
+			break
+		}
+		if lq != "" && !strings.Contains(strings.ToLower(strings.Join(rec, "\x00")), lq) {
+			continue
+		}
+		if total >= from && total < from+csvPageRows {
+			rows = append(rows, rec)
+		}
+		total++
+	}
+	if err == io.EOF {
+		err = nil
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<div class=\"data-table\">\n<form method=\"get\"><input type=\"search\" class=\"data-filter\" name=\"q\" value=\"%s\" placeholder=\"filter (enter: whole file)\"></form>\n",
+		html.EscapeString(q))
+	params := url.Values{}
+	if q != "" {
+		params.Set("q", q)
+	}
+	pager := dataPager(name, params, pageNo, total, csvPageRows)
+	buf.WriteString(pager)
+	writeDataTable(&buf, header, rows)
+	buf.WriteString(pager)
+	if err != nil {
+		buf.WriteString(extRenderError("csv", err))
+	}
+	buf.WriteString("</div>\n")
+
+	pg := page{}
+	pg.Title = filepath.Base(name) + " - mkup"
+
+	// 階層メニュー Dirnests
+	rd := filepath.Dir(name)
+	MenuDir(rd, &pg)
+
+	renderPage(w, pg, buf.Bytes())
+	return
+}
+
+// dataPager page links of a paged view, params are kept in the links
+func dataPager(name string, params url.Values, pageNo, total, per int) string {
+	pages := (total + per - 1) / per
+	if pages < 1 {
+		pages = 1
+	}
+	return pagerLinks(name, params, pageNo, pages, fmt.Sprintf("%d rows", total))
+}
+
+// pagerLinks first, prev, next and last links around page pageNo of pages
+func pagerLinks(name string, params url.Values, pageNo, pages int, count string) string {
+	link := func(n int, text string) string {
+		v := url.Values{}
+		for k, vs := range params {
+			v[k] = vs
+		}
+		v.Set("page", strconv.Itoa(n))
+		return fmt.Sprintf("<a href=\"%s?%s\">%s</a>", html.EscapeString((&url.URL{Path: name}).String()), html.EscapeString(v.Encode()), text)
+	}
+
+	var s strings.Builder
+	s.WriteString("<p class=\"data-pager\">")
+	if pageNo > 1 {
+		s.WriteString(link(1, "&laquo;") + " " + link(pageNo-1, "&lsaquo; prev") + " ")
+	}
+	fmt.Fprintf(&s, "page %d / %d (%s)", pageNo, pages, count)
+	if pageNo < pages {
+		s.WriteString(" " + link(pageNo+1, "next &rsaquo;") + " " + link(pages, "&raquo;"))
+	}
+	s.WriteString("</p>\n")
+	return s.String()
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// dataNode value of a json / yaml / toml document, keys in document order
+type dataNode struct {
+	Key      string
+	Path     string
+	Kind     string // object, array, string, number, boolean, null, datetime
+	Value    string
+	Children []*dataNode
+}
+
+// dataError parse error with position
+type dataError struct {
+	Line, Column int
+	Msg          string
+}
+
+func (e *dataError) Error() string {
+	if e.Column > 0 {
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// ParseData parse json, yaml or toml by file extension
+func ParseData(name string, b []byte) (*dataNode, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return parseJSON(b)
+	case ".toml":
+		return parseTOML(b)
+	default:
+		return parseYAML(b)
+	}
+}
+
+// parseJSON json with object key order kept
+func parseJSON(b []byte) (*dataNode, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	n, err := jsonValue(dec, "", "$")
+	if err == nil {
+		if _, err = dec.Token(); err == io.EOF {
+			return n, nil
+		} else if err == nil {
+			err = fmt.Errorf("unexpected data after top-level value")
+		}
+	}
+
+	var se *json.SyntaxError
+	if errors.As(err, &se) {
+		line, col := lineCol(b, int(se.Offset)-1)
+		return nil, &dataError{line, col, se.Error()}
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = fmt.Errorf("unexpected end of JSON input")
+	}
+	line, col := lineCol(b, int(dec.InputOffset()))
+	return nil, &dataError{line, col, err.Error()}
+}
+
+func jsonValue(dec *json.Decoder, key, path string) (*dataNode, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	n := &dataNode{Key: key, Path: path}
+	switch v := t.(type) {
+	case json.Delim:
+		if v == '{' {
+			n.Kind = "object"
+			for dec.More() {
+				kt, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				k, _ := kt.(string)
+				c, err := jsonValue(dec, k, dataPath(path, k))
+				if err != nil {
+					return nil, err
+				}
+				n.Children = append(n.Children, c)
+			}
+		} else {
+			n.Kind = "array"
+			for i := 0; dec.More(); i++ {
+				c, err := jsonValue(dec, strconv.Itoa(i), fmt.Sprintf("%s[%d]", path, i))
+				if err != nil {
+					return nil, err
+				}
+				n.Children = append(n.Children, c)
+			}
+		}
+		// 閉じ括弧
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	default:
+		n.Kind, n.Value = dataScalar(v)
+	}
+	return n, nil
+}
+
+// yamlOrdered yaml value with mappings as yaml.MapSlice in document order
+type yamlOrdered struct {
+	V interface{}
+}
+
+// UnmarshalYAML a mapping as MapSlice (the decoder keeps nested mappings
+// ordered too), a sequence item by item, else as is
+func (y *yamlOrdered) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var m yaml.MapSlice
+	if err := unmarshal(&m); err == nil {
+		y.V = m
+		return nil
+	}
+	var l []yamlOrdered
+	if err := unmarshal(&l); err == nil {
+		v := make([]interface{}, len(l))
+		for i := range l {
+			v[i] = l[i].V
+		}
+		y.V = v
+		return nil
+	}
+	return unmarshal(&y.V)
+}
+
+// UnmarshalOrdered yaml (or json) document with key order kept
+func UnmarshalOrdered(b []byte) (interface{}, error) {
+	var y yamlOrdered
+	err := yaml.Unmarshal(b, &y)
+	return y.V, err
+}
+
+var yamlErrorRe = regexp.MustCompile(`(?s)^yaml: (?:unmarshal errors:\s*)?line (\d+): (.*)$`)
+
+// parseYAML yaml (multiple documents become an array)
+func parseYAML(b []byte) (*dataNode, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	docs := []interface{}{}
+	for {
+		var y yamlOrdered
+		err := dec.Decode(&y)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if m := yamlErrorRe.FindStringSubmatch(err.Error()); m != nil {
+				line, _ := strconv.Atoi(m[1])
+				return nil, &dataError{line, 0, m[2]}
+			}
+			return nil, err
+		}
+		docs = append(docs, y.V)
+	}
+	if len(docs) == 1 {
+		return dataValue(docs[0], "", "$", nil, nil), nil
+	}
+	return dataValue(docs, "", "$", nil, nil), nil
+}
+
+// parseTOML toml, tables and keys ordered as in the file
+func parseTOML(b []byte) (*dataNode, error) {
+	var v map[string]interface{}
+	md, err := toml.Decode(string(b), &v)
+	if err != nil {
+		var pe toml.ParseError
+		if errors.As(err, &pe) {
+			line, col := lineCol(b, pe.Position.Start)
+			if line != pe.Position.Line {
+				line, col = pe.Position.Line, 0
+			}
+			return nil, &dataError{line, col, ReplaceAll(`^toml: line \d+[^:]*: `, "", pe.Error())}
+		}
+		return nil, err
+	}
+	order := map[string]int{}
+	for i, k := range md.Keys() {
+		if _, ok := order[strings.Join(k, "\x00")]; !ok {
+			order[strings.Join(k, "\x00")] = i
+		}
+	}
+	return dataValue(v, "", "$", nil, order), nil
+}
+
+// dataValue decoded value to node, map keys sorted by order of their key path (toml)
+func dataValue(v interface{}, key, path string, keys []string, order map[string]int) *dataNode {
+	n := &dataNode{Key: key, Path: path}
+	sub := func(k string) []string { return append(keys[:len(keys):len(keys)], k) }
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		n.Kind = "object"
+		for _, it := range v {
+			k := fmt.Sprint(it.Key)
+			n.Children = append(n.Children, dataValue(it.Value, k, dataPath(path, k), sub(k), order))
+		}
+	case map[string]interface{}:
+		n.Kind = "object"
+		ks := make([]string, 0, len(v))
+		for k := range v {
+			ks = append(ks, k)
+		}
+		sort.SliceStable(ks, func(i, j int) bool {
+			oi, iok := order[strings.Join(sub(ks[i]), "\x00")]
+			oj, jok := order[strings.Join(sub(ks[j]), "\x00")]
+			if iok != jok {
+				return iok
+			}
+			if oi != oj {
+				return oi < oj
+			}
+			return ks[i] < ks[j]
+		})
+		for _, k := range ks {
+			n.Children = append(n.Children, dataValue(v[k], k, dataPath(path, k), sub(k), order))
+		}
+	case []map[string]interface{}:
+		n.Kind = "array"
+		for i, c := range v {
+			n.Children = append(n.Children, dataValue(c, strconv.Itoa(i), fmt.Sprintf("%s[%d]", path, i), keys, order))
+		}
+	case []interface{}:
+		n.Kind = "array"
+		for i, c := range v {
+			n.Children = append(n.Children, dataValue(c, strconv.Itoa(i), fmt.Sprintf("%s[%d]", path, i), keys, order))
+		}
+	default:
+		n.Kind, n.Value = dataScalar(v)
+	}
+	return n
+}
+
+// dataScalar kind and display text of a scalar
+func dataScalar(v interface{}) (string, string) {
+	switch v := v.(type) {
+	case nil:
+		return "null", "null"
+	case string:
+		return "string", strconv.Quote(v)
+	case bool:
+		return "boolean", strconv.FormatBool(v)
+	case json.Number:
+		return "number", v.String()
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return "number", fmt.Sprint(v)
+	case time.Time:
+		return "datetime", v.Format(time.RFC3339Nano)
+	}
+	return "string", fmt.Sprint(v)
+}
+
+var dataIdentRe = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// dataPath json path of key k under path
+func dataPath(path, k string) string {
+	if dataIdentRe.MatchString(k) {
+		return path + "." + k
+	}
+	return path + "[" + strconv.Quote(k) + "]"
+}
+
+// lineCol 1-based line and column of byte offset
+func lineCol(b []byte, offset int) (int, int) {
+	if offset > len(b) {
+		offset = len(b)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	line := bytes.Count(b[:offset], []byte("\n")) + 1
+	col := offset - bytes.LastIndexByte(b[:offset], '\n')
+	return line, col
+}
+
+// writeDataTree node as nested lists, objects and arrays collapsible
+func writeDataTree(buf *bytes.Buffer, n *dataNode, depth int) {
+	key := ""
+	if depth > 0 {
+		key = "<span class=\"dt-key\">" + html.EscapeString(n.Key) + "</span>: "
+	}
+	cp := fmt.Sprintf("<a class=\"dt-copy\" href=\"#\" data-path=\"%s\" title=\"copy JSON path\">&#x2398;</a>", html.EscapeString(n.Path))
+	if n.Kind != "object" && n.Kind != "array" {
+		fmt.Fprintf(buf, "<li class=\"dt-leaf\">%s<span class=\"dt-value dt-%s\">%s</span> <span class=\"dt-badge dt-%s\">%s</span> %s</li>\n",
+			key, n.Kind, html.EscapeString(n.Value), n.Kind, n.Kind, cp)
+		return
+	}
+
+	open := ""
+	if depth < 3 {
+		open = " open"
+	}
+	count := fmt.Sprintf("{%d}", len(n.Children))
+	if n.Kind == "array" {
+		count = fmt.Sprintf("[%d]", len(n.Children))
+	}
+	fmt.Fprintf(buf, "<li><details%s><summary>%s<span class=\"dt-badge dt-%s\">%s</span> <span class=\"dt-count\">%s</span> %s</summary>\n<ul>\n",
+		open, key, n.Kind, n.Kind, count, cp)
+	for _, c := range n.Children {
+		writeDataTree(buf, c, depth+1)
+	}
+	buf.WriteString("</ul>\n</details></li>\n")
+}
+
+// dataview json / yaml / toml as collapsible tree, or highlighted source.
+// openapi / swagger documents are shown as api reference (?view=tree for the tree)
+func dataview(cwd string, w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Path
+	// 大きいファイルはページ単位のソース表示
+	if info, err := os.Stat(filepath.Join(cwd, name)); err == nil && info.Size() > largeFileSize {
+		fileview(cwd, w, r)
+		return
+	}
+	b, err := ioutil.ReadFile(filepath.Join(cwd, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			http.Error(w, "404 page not found", 404)
+			return
+		}
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	pg := page{}
+	pg.Title = filepath.Base(name) + " - mkup"
+
+	// openapi / swagger はリファレンスとして表示
+	var spec *apiSpec
+	if !Match(`(?i)\.toml$`, name) {
+		spec = ParseOpenAPI(cwd, name, b)
+	}
+
+	var buf bytes.Buffer
+	if spec != nil && r.FormValue("view") != "tree" {
+		buf.WriteString("<p class=\"data-tools\"><a href=\"?view=tree\">tree</a></p>\n")
+		buf.Write(spec.HTML(&pg))
+	} else {
+		buf.WriteString("<div class=\"data-view\">\n")
+		tree := false
+		if root, err := ParseData(name, b); err != nil {
+			buf.WriteString(extRenderError(strings.TrimPrefix(filepath.Ext(name), "."), err))
+		} else {
+			tree = true
+			buf.WriteString("<p class=\"data-tools\"><input type=\"search\" class=\"data-filter\" placeholder=\"filter keys and values\"> <a href=\"#\" class=\"data-toggle\">source</a>")
+			if spec != nil {
+				buf.WriteString(" <a href=\"?\">API reference</a>")
+			}
+			buf.WriteString("</p>\n<ul class=\"data-tree\">\n")
+			writeDataTree(&buf, root, 0)
+			buf.WriteString("</ul>\n")
+		}
+		if tree {
+			buf.WriteString("<div class=\"data-source\" style=\"display: none\">\n")
+		} else {
+			buf.WriteString("<div class=\"data-source\">\n")
+		}
+		buf.WriteString(Highlight(string(b), "", name, true))
+		buf.WriteString("</div>\n</div>\n")
+	}
+
+	// 階層メニュー Dirnests
+	rd := filepath.Dir(name)
+	MenuDir(rd, &pg)
+
+	renderPage(w, pg, buf.Bytes())
+	return
+}
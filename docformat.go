@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"html"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// docFormat document format, Render converts file content to html body,
+// files over MaxSize are shown as paged source instead
+type docFormat struct {
+	Name    string
+	Render  func(cwd, name string, b []byte, pg *page) ([]byte, error)
+	MaxSize int64
+}
+
+var (
+	docFormatsMu sync.RWMutex
+	docFormats   = map[string]*docFormat{}
+	fileViews    = map[string]func(cwd string, w http.ResponseWriter, r *http.Request){}
+	streamViews  = map[string]bool{}
+)
+
+func init() {
+	RegisterFormat(&docFormat{"markdown", markdownDoc, largeFileSize}, ".md", ".mkd", ".markdown")
+	RegisterFormat(&docFormat{"notebook", notebookDoc, notebookMaxSize}, ".ipynb")
+	RegisterFormat(&docFormat{"org", orgDoc, largeFileSize}, ".org")
+	RegisterStreamView(csvview, ".csv", ".tsv")
+	RegisterStreamView(xlsxview, ".xlsx", ".xlsm")
+	RegisterView(dataview, ".json", ".yaml", ".yml", ".toml")
+	RegisterStreamView(logview, ".log")
+}
+
+// RegisterFormat register f for file extensions (".org"), replaces earlier ones
+func RegisterFormat(f *docFormat, exts ...string) {
+	docFormatsMu.Lock()
+	defer docFormatsMu.Unlock()
+	for _, ext := range exts {
+		docFormats[strings.ToLower(ext)] = f
+	}
+}
+
+// FormatFor document format of file name, nil when not a document
+func FormatFor(name string) *docFormat {
+	docFormatsMu.RLock()
+	defer docFormatsMu.RUnlock()
+	return docFormats[strings.ToLower(filepath.Ext(name))]
+}
+
+// RegisterView register a handler for file extensions, for viewers that need
+// the request (paging, tabs ...) or write the page themselves
+func RegisterView(view func(cwd string, w http.ResponseWriter, r *http.Request), exts ...string) {
+	docFormatsMu.Lock()
+	defer docFormatsMu.Unlock()
+	for _, ext := range exts {
+		fileViews[strings.ToLower(ext)] = view
+		delete(streamViews, strings.ToLower(ext))
+	}
+}
+
+// RegisterStreamView register a handler that reads only what it shows, so it
+// also gets files over largeFileSize
+func RegisterStreamView(view func(cwd string, w http.ResponseWriter, r *http.Request), exts ...string) {
+	RegisterView(view, exts...)
+	docFormatsMu.Lock()
+	defer docFormatsMu.Unlock()
+	for _, ext := range exts {
+		streamViews[strings.ToLower(ext)] = true
+	}
+}
+
+// ViewFor viewer of file name, nil when none
+func ViewFor(name string) func(cwd string, w http.ResponseWriter, r *http.Request) {
+	docFormatsMu.RLock()
+	defer docFormatsMu.RUnlock()
+	return fileViews[strings.ToLower(filepath.Ext(name))]
+}
+
+// PagedSource file of size is too large for its format or view, which read
+// it at once, so it is shown paged by fileview instead
+func PagedSource(name string, size int64) bool {
+	docFormatsMu.RLock()
+	defer docFormatsMu.RUnlock()
+	ext := strings.ToLower(filepath.Ext(name))
+	if f := docFormats[ext]; f != nil {
+		return size > f.MaxSize
+	}
+	return fileViews[ext] != nil && !streamViews[ext] && size > largeFileSize
+}
+
+// IsDocument file is rendered as a document
+func IsDocument(name string) bool {
+	return FormatFor(name) != nil
+}
+
+// DocumentExts registered extensions, sorted
+func DocumentExts() []string {
+	docFormatsMu.RLock()
+	defer docFormatsMu.RUnlock()
+	exts := []string{}
+	for ext := range docFormats {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+// DocumentPattern file name regex matching documents (for ag -G)
+func DocumentPattern() string {
+	exts := []string{}
+	for _, ext := range DocumentExts() {
+		exts = append(exts, regexp.QuoteMeta(strings.TrimPrefix(ext, ".")))
+	}
+	return `(?i)\.(` + strings.Join(exts, "|") + `)$`
+}
+
+// externalFormats adapters to local converters, first installed command wins
+var externalFormats = []struct {
+	Name     string
+	Exts     []string
+	Commands []string
+}{
+	{"asciidoc", []string{".adoc", ".asciidoc", ".asc"}, []string{"asciidoctor -s -a showtitle -o - -"}},
+	{"rst", []string{".rst", ".rest"}, []string{
+		"rst2html",
+		"rst2html.py",
+		"pandoc -f rst -t html",
+	}},
+	{"textile", []string{".textile"}, []string{"pandoc -f textile -t html"}},
+	{"mediawiki", []string{".mediawiki", ".wiki"}, []string{"pandoc -f mediawiki -t html"}},
+}
+
+// RegisterExternalFormats register adapters whose command is installed
+func RegisterExternalFormats() {
+	for _, ef := range externalFormats {
+		for _, command := range ef.Commands {
+			if _, err := exec.LookPath(strings.Fields(command)[0]); err != nil {
+				continue
+			}
+			log.Printf("format %s: %s", ef.Name, command)
+			RegisterFormat(externalFormat(ef.Name, command), ef.Exts...)
+			break
+		}
+	}
+}
+
+func externalFormat(name, command string) *docFormat {
+	return &docFormat{name, func(cwd, fn string, b []byte, pg *page) ([]byte, error) {
+		s, err := ExtRender(name, command, b)
+		if err != nil {
+			return nil, err
+		}
+		// rst2html などは完全な html 文書を返す
+		if title := htmlTitleRe.FindStringSubmatch(s); title != nil && htmlBodyRe.MatchString(s) {
+			pg.Title = html.UnescapeString(strings.TrimSpace(title[1])) + " - mkup"
+		}
+		if m := htmlBodyRe.FindStringSubmatch(s); m != nil {
+			s = m[1]
+		}
+		return HeadingToc([]byte(s), pg), nil
+	}, largeFileSize}
+}
+
+var (
+	htmlBodyRe  = regexp.MustCompile(`(?is)<body[^>]*>(.*)</body>`)
+	htmlTitleRe = regexp.MustCompile(`(?is)<title>(.*?)</title>`)
+)
+
+// docview render document file with its format
+func docview(cwd string, f *docFormat, w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Path
+	b, err := ioutil.ReadFile(filepath.Join(cwd, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			http.Error(w, "404 page not found", 404)
+			return
+		}
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	pg := page{}
+	pg.Title = filepath.Base(name) + " - mkup"
+
+	out, err := f.Render(cwd, name, b, &pg)
+	if err != nil {
+		// 変換できない時はソースを表示
+		var buf bytes.Buffer
+		buf.WriteString(extRenderError(f.Name, err))
+		buf.WriteString(Highlight(string(b), "", name, true))
+		out = buf.Bytes()
+	}
+
+	// 階層メニュー Dirnests
+	rd := filepath.Dir(name)
+	MenuDir(rd, &pg)
+
+	renderPage(w, pg, out)
+	return
+}
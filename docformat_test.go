@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPagedSource(t *testing.T) {
+	tests := []struct {
+		name string
+		size int64
+		want bool
+	}{
+		{"a.md", largeFileSize, false},
+		{"a.md", largeFileSize + 1, true},
+		{"A.ORG", largeFileSize + 1, true},
+		{"a.ipynb", largeFileSize + 1, false},
+		{"a.ipynb", notebookMaxSize + 1, true},
+		{"a.json", largeFileSize + 1, true},
+		{"a.json", largeFileSize, false},
+		{"a.csv", 1 << 30, false},
+		{"a.xlsx", 1 << 30, false},
+		{"a.go", 1 << 30, false},
+	}
+	for _, tt := range tests {
+		if got := PagedSource(tt.name, tt.size); got != tt.want {
+			t.Errorf("PagedSource(%q, %d) = %v, want %v", tt.name, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestDocviewLargeNotebook(t *testing.T) {
+	// 画像の出力で 1 MiB を超えるノートブック
+	png := base64.StdEncoding.EncodeToString(make([]byte, largeFileSize))
+	nb := map[string]interface{}{
+		"nbformat": 4,
+		"cells": []interface{}{
+			map[string]interface{}{"cell_type": "markdown", "source": "# Plot"},
+			map[string]interface{}{
+				"cell_type": "code",
+				"source":    "plot()",
+				"outputs": []interface{}{
+					map[string]interface{}{"output_type": "display_data", "data": map[string]interface{}{"image/png": png}},
+				},
+			},
+		},
+	}
+	b, err := json.Marshal(nb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) <= largeFileSize {
+		t.Fatalf("notebook is only %d bytes", len(b))
+	}
+	cwd := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(cwd, "big.ipynb"), b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if PagedSource("big.ipynb", int64(len(b))) {
+		t.Fatal("big notebook goes to the paged source view")
+	}
+	w := httptest.NewRecorder()
+	docview(cwd, FormatFor("big.ipynb"), w, httptest.NewRequest("GET", "/big.ipynb", nil))
+	out := w.Body.String()
+	if !strings.Contains(out, "<img") || !strings.Contains(out, "Plot</h1>") {
+		t.Errorf("notebook not rendered: %.300q", out)
+	}
+}
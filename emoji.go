@@ -0,0 +1,20 @@
+package main
+
+import (
+	"regexp"
+
+	"github.com/kyokomi/emoji/v2"
+)
+
+var emojiRe = regexp.MustCompile(`:[a-z0-9_+\-]+:`)
+
+// Emojize :tada: shortcodes to unicode emoji
+func Emojize(s string) string {
+	codes := emoji.CodeMap()
+	return emojiRe.ReplaceAllStringFunc(s, func(m string) string {
+		if e, ok := codes[m]; ok {
+			return e
+		}
+		return m
+	})
+}
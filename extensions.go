@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/russross/blackfriday"
+)
+
+const (
+	defaultExtensions = blackfriday.EXTENSION_NO_INTRA_EMPHASIS |
+		blackfriday.EXTENSION_TABLES |
+		blackfriday.EXTENSION_FENCED_CODE |
+		blackfriday.EXTENSION_AUTOLINK |
+		blackfriday.EXTENSION_STRIKETHROUGH |
+		blackfriday.EXTENSION_SPACE_HEADERS
+	defaultHTMLFlags = 0
+)
+
+// extensionNames blackfriday extensions by name
+var extensionNames = map[string]int{
+	"no_intra_emphasis":          blackfriday.EXTENSION_NO_INTRA_EMPHASIS,
+	"tables":                     blackfriday.EXTENSION_TABLES,
+	"fenced_code":                blackfriday.EXTENSION_FENCED_CODE,
+	"autolink":                   blackfriday.EXTENSION_AUTOLINK,
+	"strikethrough":              blackfriday.EXTENSION_STRIKETHROUGH,
+	"lax_html_blocks":            blackfriday.EXTENSION_LAX_HTML_BLOCKS,
+	"space_headers":              blackfriday.EXTENSION_SPACE_HEADERS,
+	"hard_line_break":            blackfriday.EXTENSION_HARD_LINE_BREAK,
+	"tab_size_eight":             blackfriday.EXTENSION_TAB_SIZE_EIGHT,
+	"footnotes":                  blackfriday.EXTENSION_FOOTNOTES,
+	"no_empty_line_before_block": blackfriday.EXTENSION_NO_EMPTY_LINE_BEFORE_BLOCK,
+	"header_ids":                 blackfriday.EXTENSION_HEADER_IDS,
+	"titleblock":                 blackfriday.EXTENSION_TITLEBLOCK,
+	"auto_header_ids":            blackfriday.EXTENSION_AUTO_HEADER_IDS,
+	"backslash_line_break":       blackfriday.EXTENSION_BACKSLASH_LINE_BREAK,
+	"definition_lists":           blackfriday.EXTENSION_DEFINITION_LISTS,
+	"join_lines":                 blackfriday.EXTENSION_JOIN_LINES,
+}
+
+// htmlFlagNames blackfriday html renderer flags by name
+var htmlFlagNames = map[string]int{
+	"skip_html":                 blackfriday.HTML_SKIP_HTML,
+	"skip_style":                blackfriday.HTML_SKIP_STYLE,
+	"skip_images":               blackfriday.HTML_SKIP_IMAGES,
+	"skip_links":                blackfriday.HTML_SKIP_LINKS,
+	"safelink":                  blackfriday.HTML_SAFELINK,
+	"nofollow_links":            blackfriday.HTML_NOFOLLOW_LINKS,
+	"noreferrer_links":          blackfriday.HTML_NOREFERRER_LINKS,
+	"noopener_links":            blackfriday.HTML_NOOPENER_LINKS,
+	"href_target_blank":         blackfriday.HTML_HREF_TARGET_BLANK,
+	"use_xhtml":                 blackfriday.HTML_USE_XHTML,
+	"smartypants":               blackfriday.HTML_USE_SMARTYPANTS,
+	"smartypants_fractions":     blackfriday.HTML_SMARTYPANTS_FRACTIONS,
+	"smartypants_dashes":        blackfriday.HTML_SMARTYPANTS_DASHES,
+	"smartypants_latex_dashes":  blackfriday.HTML_SMARTYPANTS_LATEX_DASHES,
+	"smartypants_angled_quotes": blackfriday.HTML_SMARTYPANTS_ANGLED_QUOTES,
+	"footnote_return_links":     blackfriday.HTML_FOOTNOTE_RETURN_LINKS,
+}
+
+// ParseFlagSet apply "name,+name,-name,default,none" to base
+func ParseFlagSet(base, def int, spec string, names map[string]int) (int, error) {
+	flags := base
+	for _, f := range strings.FieldsFunc(spec, func(r rune) bool { return r == ',' || r == ' ' }) {
+		f = strings.ToLower(f)
+		switch f {
+		case "default":
+			flags = def
+			continue
+		case "none":
+			flags = 0
+			continue
+		}
+
+		remove := strings.HasPrefix(f, "-")
+		n := strings.TrimLeft(f, "+-")
+		v, ok := names[n]
+		if !ok {
+			return base, fmt.Errorf("unknown flag %q (%s)", n, strings.Join(FlagNames(names), ", "))
+		}
+		if remove {
+			flags &^= v
+		} else {
+			flags |= v
+		}
+	}
+	return flags, nil
+}
+
+// FlagNames sorted names of a flag set
+func FlagNames(names map[string]int) []string {
+	s := []string{}
+	for n := range names {
+		s = append(s, n)
+	}
+	sort.Strings(s)
+	return s
+}
+
+// MarkdownFlags extensions and html flags: default < config < command line < front matter
+func MarkdownFlags(meta map[string]interface{}) (ext int, hf int, err error) {
+	c := Config()
+	layers := []struct{ ext, hf string }{
+		{strings.Join(c.Markdown.Extensions, ","), strings.Join(c.Markdown.HTMLFlags, ",")},
+		{*extFlags, *htmlFlags},
+		{MetaString(meta, "extensions"), MetaString(meta, "html_flags")},
+	}
+
+	ext, hf = defaultExtensions, defaultHTMLFlags
+	for _, l := range layers {
+		if ext, err = ParseFlagSet(ext, defaultExtensions, l.ext, extensionNames); err != nil {
+			return
+		}
+		if hf, err = ParseFlagSet(hf, defaultHTMLFlags, l.hf, htmlFlagNames); err != nil {
+			return
+		}
+	}
+	return
+}
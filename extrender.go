@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	extRenderTimeout  = 30 * time.Second
+	extRenderCacheMax = 512
+)
+
+var (
+	extCacheMu sync.Mutex
+	extCache   = map[string]string{}
+)
+
+// ExtRender pipe fenced block through external command, cached by content hash
+func ExtRender(lang, command string, src []byte) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", lang, command)
+	h.Write(src)
+	key := hex.EncodeToString(h.Sum(nil))
+
+	extCacheMu.Lock()
+	s, ok := extCache[key]
+	extCacheMu.Unlock()
+	if ok {
+		return s, nil
+	}
+
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", fmt.Errorf("empty command")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), extRenderTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(src)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%v: %s", err, msg)
+		}
+		return "", err
+	}
+
+	// svg は xml 宣言, doctype を除いてインライン化
+	s = stdout.String()
+	s = ReplaceAll(`(?s)^\s*<\?xml.*?\?>`, "", s)
+	s = ReplaceAll(`(?s)^\s*<!DOCTYPE[^>]*>`, "", s)
+	s = ReplaceAll(`(?s)^\s*<!--.*?-->`, "", s)
+
+	extCacheMu.Lock()
+	if len(extCache) >= extRenderCacheMax {
+		extCache = map[string]string{}
+	}
+	extCache[key] = s
+	extCacheMu.Unlock()
+	return s, nil
+}
+
+// extRenderError inline error box
+func extRenderError(lang string, err error) string {
+	return fmt.Sprintf("<div class=\"ext-render-error\"><strong>%s</strong>: %s</div>\n",
+		html.EscapeString(lang), html.EscapeString(err.Error()))
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// fileIndex files under the served root by lower case base name
+type fileIndex struct {
+	mu    sync.Mutex
+	root  string
+	names map[string][]string
+}
+
+var files = &fileIndex{}
+
+// Invalidate drop the index (fsnotify create/remove/rename)
+func (fi *fileIndex) Invalidate() {
+	fi.mu.Lock()
+	fi.names = nil
+	fi.mu.Unlock()
+}
+
+// Lookup slash separated paths (relative to root) of files named name,
+// "name.md" etc. also match a bare page name
+func (fi *fileIndex) Lookup(root, name string) []string {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	if fi.names == nil || fi.root != root {
+		fi.build(root)
+	}
+	return fi.names[strings.ToLower(name)]
+}
+
+func (fi *fileIndex) build(root string) {
+	fi.root = root
+	fi.names = map[string][]string{}
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info == nil {
+			return nil
+		}
+		if path != root && Match("^[\\._]", info.Name()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rd, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		rd = filepath.ToSlash(rd)
+		base := strings.ToLower(info.Name())
+		fi.names[base] = append(fi.names[base], rd)
+		if ext := filepath.Ext(base); Match(`^\.(md|markdown|mkd)$`, ext) {
+			page := strings.TrimSuffix(base, ext)
+			fi.names[page] = append(fi.names[page], rd)
+		}
+		return nil
+	})
+	for _, v := range fi.names {
+		sort.Strings(v)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// metaEntry front matter field
+type metaEntry struct {
+	Key   string
+	Value string
+}
+
+// FrontMatter split yaml (---) or toml (+++) front matter from markdown
+func FrontMatter(b []byte) (meta map[string]interface{}, body []byte, err error) {
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+	delim := ""
+	if bytes.HasPrefix(b, []byte("---")) {
+		delim = "---"
+	} else if bytes.HasPrefix(b, []byte("+++")) {
+		delim = "+++"
+	} else {
+		return nil, b, nil
+	}
+
+	lines := strings.SplitAfter(string(b), "\n")
+	if strings.TrimSpace(lines[0]) != delim {
+		return nil, b, nil
+	}
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		l := strings.TrimSpace(lines[i])
+		if l == delim || (delim == "---" && l == "...") {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return nil, b, nil
+	}
+
+	src := strings.Join(lines[1:end], "")
+	body = []byte(strings.Join(lines[end+1:], ""))
+	meta = map[string]interface{}{}
+	if delim == "---" {
+		err = yaml.Unmarshal([]byte(src), &meta)
+	} else {
+		_, err = toml.Decode(src, &meta)
+	}
+	return meta, body, err
+}
+
+// MetaString front matter string value
+func MetaString(meta map[string]interface{}, key string) string {
+	if v, ok := meta[key]; ok && v != nil {
+		return metaValue(v)
+	}
+	return ""
+}
+
+// metaHidden front matter keys not shown in the panel
+var metaHidden = map[string]bool{"title": true, "extensions": true, "html_flags": true}
+
+// MetaEntries front matter fields for display (sorted, without directives)
+func MetaEntries(meta map[string]interface{}) []metaEntry {
+	keys := []string{}
+	for k := range meta {
+		if metaHidden[k] {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	entries := []metaEntry{}
+	for _, k := range keys {
+		entries = append(entries, metaEntry{k, metaValue(meta[k])})
+	}
+	return entries
+}
+
+func metaValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case time.Time:
+		if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+			return t.Format("2006-01-02")
+		}
+		return t.Format("2006-01-02 15:04:05")
+	case []interface{}:
+		s := []string{}
+		for _, e := range t {
+			s = append(s, metaValue(e))
+		}
+		return strings.Join(s, ", ")
+	case map[interface{}]interface{}, map[string]interface{}:
+		s := []string{}
+		for k, e := range toStringMap(t) {
+			s = append(s, k+": "+metaValue(e))
+		}
+		sort.Strings(s)
+		return "{" + strings.Join(s, ", ") + "}"
+	}
+	return fmt.Sprint(v)
+}
+
+func toStringMap(v interface{}) map[string]interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		return t
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for k, e := range t {
+			m[fmt.Sprint(k)] = e
+		}
+		return m
+	}
+	return nil
+}
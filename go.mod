@@ -0,0 +1,29 @@
+module github.com/mattn/mkup
+
+go 1.23.0
+
+require (
+	github.com/BurntSushi/toml v1.2.1
+	github.com/alecthomas/chroma/v2 v2.14.0
+	github.com/kyokomi/emoji/v2 v2.2.13
+	github.com/niklasfasching/go-org v1.9.1
+	github.com/russross/blackfriday v1.6.0
+	github.com/xuri/excelize/v2 v2.9.1
+	golang.org/x/exp v0.0.0-20220303212507-bbda1eaf7a17
+	golang.org/x/net v0.40.0
+	gopkg.in/fsnotify.v1 v1.4.7
+	gopkg.in/yaml.v2 v2.4.0
+)
+
+require (
+	github.com/dlclark/regexp2 v1.11.0 // indirect
+	github.com/fsnotify/fsnotify v1.10.1 // indirect
+	github.com/richardlehane/mscfb v1.0.4 // indirect
+	github.com/richardlehane/msoleps v1.0.4 // indirect
+	github.com/tiendc/go-deepcopy v1.6.0 // indirect
+	github.com/xuri/efp v0.0.1 // indirect
+	github.com/xuri/nfp v0.0.1 // indirect
+	golang.org/x/crypto v0.38.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
+	golang.org/x/text v0.25.0 // indirect
+)
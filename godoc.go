@@ -0,0 +1,378 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/doc/comment"
+	"go/parser"
+	"go/printer"
+	"go/scanner"
+	"go/token"
+	"html"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// goPkg package documentation of a source directory
+type goPkg struct {
+	cwd   string
+	fset  *token.FileSet
+	files map[string]*ast.File
+	pkg   *doc.Package
+	bp    *build.Package
+	pr    *comment.Printer
+}
+
+var (
+	goModuleRe      = regexp.MustCompile(`(?m)^module\s+"?([^\s"]+)"?`)
+	exampleOutputRe = regexp.MustCompile(`(?i)^[[:space:]]*(unordered )?output:`)
+)
+
+// GoPackageName name of the Go package in dir, "" when there is none
+func GoPackageName(dir string) string {
+	bp, err := build.Default.ImportDir(dir, 0)
+	if err != nil || len(bp.GoFiles)+len(bp.CgoFiles) == 0 {
+		return ""
+	}
+	return bp.Name
+}
+
+// ParseGoPackage parse the package in dir with its tests (for examples)
+func ParseGoPackage(cwd, dir string) (*goPkg, error) {
+	bp, err := build.Default.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	g := &goPkg{cwd: cwd, fset: token.NewFileSet(), files: map[string]*ast.File{}, bp: bp}
+	names := append(append(append(append([]string{}, bp.GoFiles...), bp.CgoFiles...), bp.TestGoFiles...), bp.XTestGoFiles...)
+	files := []*ast.File{}
+	for _, fn := range names {
+		fp := filepath.Join(dir, fn)
+		f, err := parser.ParseFile(g.fset, fp, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		g.files[fp] = f
+		files = append(files, f)
+	}
+	g.pkg, err = doc.NewFromFiles(g.fset, files, GoImportPath(dir))
+	if err != nil {
+		return nil, err
+	}
+	g.pr = g.pkg.Printer()
+	g.pr.HeadingLevel = 3
+	g.pr.DocLinkBaseURL = "https://pkg.go.dev"
+	return g, nil
+}
+
+// doc doc comment to html, [Name] links to the declarations
+func (g *goPkg) doc(text string) []byte {
+	return g.pr.HTML(g.pkg.Parser().Parse(text))
+}
+
+// GoImportPath import path of dir from the nearest go.mod, or the directory name
+func GoImportPath(dir string) string {
+	for d := dir; ; d = filepath.Dir(d) {
+		if b, err := ioutil.ReadFile(filepath.Join(d, "go.mod")); err == nil {
+			if m := goModuleRe.FindSubmatch(b); m != nil {
+				rel, _ := filepath.Rel(d, dir)
+				return strings.TrimSuffix(string(m[1])+"/"+filepath.ToSlash(rel), "/.")
+			}
+		}
+		if filepath.Dir(d) == d {
+			return filepath.Base(dir)
+		}
+	}
+}
+
+// source code view url of pos (file.go#L12)
+func (g *goPkg) source(pos token.Pos) string {
+	p := g.fset.Position(pos)
+	rel, err := filepath.Rel(g.cwd, p.Filename)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("/%s#L%d", filepath.ToSlash(rel), p.Line)
+}
+
+// HTML overview, index, declarations, examples and files
+func (g *goPkg) HTML(pg *page) []byte {
+	var buf bytes.Buffer
+	pkg := g.pkg
+
+	fmt.Fprintf(&buf, "<h1 id=\"pkg-top\">package %s</h1>\n", html.EscapeString(pkg.Name))
+	fmt.Fprintf(&buf, "<p><code>import \"%s\"</code></p>\n", html.EscapeString(pkg.ImportPath))
+
+	buf.WriteString("<h2 id=\"pkg-overview\">Overview</h2>\n")
+	buf.Write(g.doc(pkg.Doc))
+	g.examples(&buf, pkg.Examples)
+
+	buf.WriteString("<h2 id=\"pkg-index\">Index</h2>\n<ul class=\"godoc-index\">\n")
+	if len(pkg.Consts) > 0 {
+		buf.WriteString("<li><a href=\"#pkg-constants\">Constants</a></li>\n")
+	}
+	if len(pkg.Vars) > 0 {
+		buf.WriteString("<li><a href=\"#pkg-variables\">Variables</a></li>\n")
+	}
+	for _, f := range pkg.Funcs {
+		g.indexFunc(&buf, f, f.Name)
+	}
+	for _, t := range pkg.Types {
+		fmt.Fprintf(&buf, "<li><a href=\"#%s\">type %s</a>\n<ul>\n", t.Name, t.Name)
+		for _, f := range t.Funcs {
+			g.indexFunc(&buf, f, f.Name)
+		}
+		for _, m := range t.Methods {
+			g.indexFunc(&buf, m, t.Name+"."+m.Name)
+		}
+		buf.WriteString("</ul>\n</li>\n")
+	}
+	buf.WriteString("</ul>\n")
+
+	if len(pkg.Consts) > 0 {
+		buf.WriteString("<h2 id=\"pkg-constants\">Constants</h2>\n")
+		g.values(&buf, pkg.Consts)
+	}
+	if len(pkg.Vars) > 0 {
+		buf.WriteString("<h2 id=\"pkg-variables\">Variables</h2>\n")
+		g.values(&buf, pkg.Vars)
+	}
+	if len(pkg.Funcs) > 0 {
+		buf.WriteString("<h2 id=\"pkg-functions\">Functions</h2>\n")
+		for _, f := range pkg.Funcs {
+			g.fn(&buf, 3, f, f.Name)
+		}
+	}
+	if len(pkg.Types) > 0 {
+		buf.WriteString("<h2 id=\"pkg-types\">Types</h2>\n")
+	}
+	for _, t := range pkg.Types {
+		spec := t.Decl.Specs[0].(*ast.TypeSpec)
+		fmt.Fprintf(&buf, "<h3 id=\"%s\">type <a href=\"%s\">%s</a></h3>\n",
+			t.Name, html.EscapeString(g.source(spec.Name.Pos())), t.Name)
+		g.decl(&buf, t.Decl, t.Name)
+		buf.Write(g.doc(t.Doc))
+		g.values(&buf, t.Consts)
+		g.values(&buf, t.Vars)
+		g.examples(&buf, t.Examples)
+		for _, f := range t.Funcs {
+			g.fn(&buf, 4, f, f.Name)
+		}
+		for _, m := range t.Methods {
+			g.fn(&buf, 4, m, t.Name+"."+m.Name)
+		}
+	}
+
+	buf.WriteString("<h2 id=\"pkg-files\">Source files</h2>\n<ul>\n")
+	for _, fn := range append(append([]string{}, g.bp.GoFiles...), g.bp.CgoFiles...) {
+		href := fn
+		if rel, err := filepath.Rel(g.cwd, filepath.Join(g.bp.Dir, fn)); err == nil {
+			href = "/" + filepath.ToSlash(rel)
+		}
+		fmt.Fprintf(&buf, "<li><a href=\"%s\">%s</a></li>\n", html.EscapeString(href), html.EscapeString(fn))
+	}
+	buf.WriteString("</ul>\n")
+
+	return HeadingToc(buf.Bytes(), pg)
+}
+
+// indexFunc index entry with the one line signature
+func (g *goPkg) indexFunc(buf *bytes.Buffer, f *doc.Func, id string) {
+	fmt.Fprintf(buf, "<li><a href=\"#%s\">%s</a></li>\n", id, html.EscapeString(g.print(f.Decl, nil)))
+}
+
+// fn func or method with signature, doc and examples
+func (g *goPkg) fn(buf *bytes.Buffer, level int, f *doc.Func, id string) {
+	recv := ""
+	if f.Recv != "" {
+		recv = "(" + html.EscapeString(f.Recv) + ") "
+	}
+	fmt.Fprintf(buf, "<h%d id=\"%s\">func %s<a href=\"%s\">%s</a></h%d>\n",
+		level, id, recv, html.EscapeString(g.source(f.Decl.Name.Pos())), f.Name, level)
+	g.decl(buf, f.Decl, "")
+	buf.Write(g.doc(f.Doc))
+	g.examples(buf, f.Examples)
+}
+
+// values const or var groups
+func (g *goPkg) values(buf *bytes.Buffer, vs []*doc.Value) {
+	for _, v := range vs {
+		g.decl(buf, v.Decl, "")
+		buf.Write(g.doc(v.Doc))
+	}
+}
+
+// examples collapsible example code and output
+func (g *goPkg) examples(buf *bytes.Buffer, exs []*doc.Example) {
+	for _, ex := range exs {
+		title := "Example"
+		if ex.Suffix != "" {
+			title += " (" + strings.Replace(ex.Suffix, "_", " ", -1) + ")"
+		}
+		fmt.Fprintf(buf, "<details class=\"godoc-example\" id=\"%s\">\n<summary>%s</summary>\n",
+			html.EscapeString(strings.TrimSuffix("example-"+ex.Name, "-")), html.EscapeString(title))
+		buf.Write(g.doc(ex.Doc))
+		// Output: コメントは出力として別に表示
+		comments := []*ast.CommentGroup{}
+		for _, c := range ex.Comments {
+			if !exampleOutputRe.MatchString(c.Text()) {
+				comments = append(comments, c)
+			}
+		}
+		code := g.print(ex.Code, comments)
+		// { ... } の中身だけ
+		if strings.HasPrefix(code, "{") && strings.HasSuffix(code, "}") {
+			lines := strings.Split(strings.Trim(code[1:len(code)-1], "\n"), "\n")
+			for i, l := range lines {
+				lines[i] = strings.TrimPrefix(l, "\t")
+			}
+			code = strings.Join(lines, "\n")
+		}
+		buf.WriteString(Highlight(code, "go", "", false))
+		if ex.Output != "" || ex.EmptyOutput {
+			buf.WriteString("<p class=\"godoc-output\">Output:</p>\n")
+			fmt.Fprintf(buf, "<pre><code>%s</code></pre>\n", html.EscapeString(ex.Output))
+		}
+		buf.WriteString("</details>\n")
+	}
+}
+
+// print go source of node, with comments when given
+func (g *goPkg) print(node ast.Node, comments []*ast.CommentGroup) string {
+	var buf bytes.Buffer
+	conf := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	var n interface{} = node
+	if comments != nil {
+		n = &printer.CommentedNode{Node: node, Comments: comments}
+	}
+	if err := conf.Fprint(&buf, g.fset, n); err != nil {
+		return err.Error()
+	}
+	return buf.String()
+}
+
+// decl declaration highlighted, declared names link to their source line,
+// consts, vars and fields (Type.Field) get an anchor
+func (g *goPkg) decl(buf *bytes.Buffer, node ast.Node, owner string) {
+	var comments []*ast.CommentGroup
+	if f := g.files[g.fset.Position(node.Pos()).Filename]; f != nil {
+		comments = f.Comments
+	}
+	src := g.print(node, comments)
+
+	// 宣言された名前 (出力順)
+	type declName struct {
+		ident  *ast.Ident
+		anchor string
+	}
+	names := []declName{}
+	var inspect func(n ast.Node) bool
+	inspect = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			names = append(names, declName{n.Name, ""})
+			return false
+		case *ast.TypeSpec:
+			names = append(names, declName{n.Name, ""})
+			ast.Inspect(n.Type, inspect)
+			return false
+		case *ast.ValueSpec:
+			for _, id := range n.Names {
+				names = append(names, declName{id, id.Name})
+			}
+		case *ast.Field:
+			for _, id := range n.Names {
+				names = append(names, declName{id, owner + "." + id.Name})
+			}
+		case *ast.FuncType, *ast.FuncLit, *ast.CompositeLit:
+			return false
+		}
+		return true
+	}
+	ast.Inspect(node, inspect)
+
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	s.Init(fset.AddFile("", -1, len(src)), []byte(src), nil, scanner.ScanComments)
+	buf.WriteString("<pre class=\"chroma godoc-decl\"><code>")
+	last := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+		off := fset.Position(pos).Offset
+		text := tok.String()
+		if lit != "" {
+			text = lit
+		}
+		buf.WriteString(html.EscapeString(src[last:off]))
+		last = off + len(text)
+		esc := html.EscapeString(text)
+		switch {
+		case tok == token.COMMENT:
+			fmt.Fprintf(buf, "<span class=\"c1\">%s</span>", esc)
+		case tok.IsKeyword():
+			fmt.Fprintf(buf, "<span class=\"k\">%s</span>", esc)
+		case tok == token.STRING || tok == token.CHAR:
+			fmt.Fprintf(buf, "<span class=\"s\">%s</span>", esc)
+		case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+			fmt.Fprintf(buf, "<span class=\"m\">%s</span>", esc)
+		case tok == token.IDENT && len(names) > 0 && names[0].ident.Name == text:
+			n := names[0]
+			names = names[1:]
+			if !n.ident.IsExported() {
+				buf.WriteString(esc)
+				break
+			}
+			id := ""
+			if n.anchor != "" && !strings.HasPrefix(n.anchor, ".") {
+				id = fmt.Sprintf(" id=\"%s\"", html.EscapeString(n.anchor))
+			}
+			fmt.Fprintf(buf, "<a%s href=\"%s\">%s</a>", id, html.EscapeString(g.source(n.ident.Pos())), esc)
+		default:
+			buf.WriteString(esc)
+		}
+	}
+	buf.WriteString(html.EscapeString(src[last:]))
+	buf.WriteString("</code></pre>\n")
+}
+
+// godocview package documentation tab of a directory
+func godocview(cwd string, w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Path
+	dir := filepath.Join(cwd, name)
+
+	pg := page{}
+	pg.Title = filepath.Base(dir) + " - mkup"
+
+	// 階層メニュー Dirnests
+	rd, _ := filepath.Rel(cwd, dir)
+	MenuDir(rd, &pg)
+
+	var buf bytes.Buffer
+	tab := "doc"
+	g, err := ParseGoPackage(cwd, dir)
+	if err == nil {
+		tab = "package " + g.pkg.Name
+		pg.Title = tab + " - mkup"
+	}
+	fmt.Fprintf(&buf, "<ul class=\"sheet-tabs\">\n<li><a href=\"?\">files</a></li>\n<li class=\"active\"><a href=\"?view=doc\">%s</a></li>\n</ul>\n", html.EscapeString(tab))
+	if err != nil {
+		buf.WriteString(extRenderError("go/doc", err))
+	} else {
+		buf.Write(g.HTML(&pg))
+	}
+
+	renderPage(w, pg, buf.Bytes())
+	return
+}
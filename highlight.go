@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// ThemeNames available -theme values
+func ThemeNames() []string {
+	names := styles.Names()
+	sort.Strings(names)
+	return names
+}
+
+// ThemeCSS stylesheet for highlighted code
+func ThemeCSS(theme string) (string, error) {
+	style, ok := styles.Registry[theme]
+	if !ok {
+		return "", fmt.Errorf("unknown theme %q (%s)", theme, strings.Join(ThemeNames(), ", "))
+	}
+	var buf bytes.Buffer
+	if err := chromahtml.New(chromahtml.WithClasses(true)).WriteCSS(&buf, style); err != nil {
+		return "", err
+	}
+	// github-markdown.css の pre 背景より優先させる
+	return strings.Replace(buf.String(), "*/ .chroma", "*/ .markdown-body .chroma", -1), nil
+}
+
+// Highlight source to html, lexer by language name or file name
+func Highlight(src, lang, filename string, linenos bool) string {
+	return highlight(src, lang, filename, chromahtml.WithLineNumbers(linenos))
+}
+
+// HighlightFile source file for the code view, line numbers link to #L12
+func HighlightFile(src, filename string) string {
+	return HighlightFrom(src, filename, 1)
+}
+
+// HighlightFrom part of a source file starting at line
+func HighlightFrom(src, filename string, line int) string {
+	return highlight(src, "", filename, chromahtml.WithLineNumbers(true), chromahtml.WithLinkableLineNumbers(true, "L"),
+		chromahtml.BaseLineNumber(line))
+}
+
+// SourceLink code view url of line in file (documents by ?view=source),
+// large files by ?line= to the page of the line
+func SourceLink(cwd, file, line string) string {
+	u := "/" + strings.TrimPrefix(file, "/")
+	q := url.Values{}
+	if IsDocument(file) || ViewFor(file) != nil {
+		q.Set("view", "source")
+	}
+	if info, err := os.Stat(filepath.Join(cwd, file)); err == nil && info.Size() > largeFileSize {
+		q.Set("line", line)
+		return u + "?" + q.Encode()
+	}
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	return u + "#L" + line
+}
+
+func highlight(src, lang, filename string, opts ...chromahtml.Option) string {
+	var lexer chroma.Lexer
+	if lang != "" {
+		lexer = lexers.Get(lang)
+	}
+	if lexer == nil && filename != "" {
+		lexer = lexers.Match(filepath.Base(filename))
+	}
+	if lexer == nil && lang == "" {
+		lexer = lexers.Analyse(src)
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	formatter := chromahtml.New(append([]chromahtml.Option{
+		chromahtml.WithClasses(true),
+		chromahtml.LineNumbersInTable(true),
+	}, opts...)...)
+	it, err := lexer.Tokenise(nil, src)
+	if err != nil {
+		return "<pre><code>" + html.EscapeString(src) + "</code></pre>"
+	}
+	var buf bytes.Buffer
+	if err := formatter.Format(&buf, styles.Fallback, it); err != nil {
+		return "<pre><code>" + html.EscapeString(src) + "</code></pre>"
+	}
+	return buf.String()
+}
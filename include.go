@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const includeMaxDepth = 16
+
+var (
+	includeCommentRe   = regexp.MustCompile(`^\s*<!--\s*include:\s*(.+?)\s*-->\s*$`)
+	includeShortcodeRe = regexp.MustCompile(`^\s*\{\{<\s*include\s+"([^"]+)"\s*>\}\}\s*$`)
+	includeRangeRe     = regexp.MustCompile(`^L(\d+)(?:-L?(\d+))?$`)
+)
+
+// ExpandIncludes expand include directives in markdown of file fp
+//
+//	<!-- include: ../cmd/server/main.go#L10-L40 -->
+//	{{< include "other.md" >}}
+//	![[Other Note]]  (obsidian embed on its own line)
+func ExpandIncludes(cwd, fp string, b []byte) []byte {
+	return expandIncludes(cwd, fp, b, []string{realPath(fp)})
+}
+
+func expandIncludes(cwd, fp string, b []byte, stack []string) []byte {
+	var out strings.Builder
+	fence := codeFence{}
+	for _, l := range strings.SplitAfter(string(b), "\n") {
+		if fence.Skip(l) {
+			out.WriteString(l)
+			continue
+		}
+
+		target := ""
+		t := strings.TrimRight(l, "\r\n")
+		if m := includeCommentRe.FindStringSubmatch(t); m != nil {
+			target = m[1]
+		} else if m := includeShortcodeRe.FindStringSubmatch(t); m != nil {
+			target = m[1]
+		} else if m := wikiEmbedRe.FindStringSubmatch(t); m != nil {
+			// markdown への埋め込みだけ展開, 画像などは WikiLinks で
+			rd, _ := filepath.Rel(cwd, fp)
+			name := parseWikiTarget(m[1]).Name
+			if rel := ResolveWiki(cwd, filepath.ToSlash(rd), name); name != "" && Match(`(?i)\.(md|markdown|mkd)$`, rel) {
+				target = "/" + rel
+			}
+		}
+		if target == "" {
+			out.WriteString(l)
+			continue
+		}
+
+		s, err := includeFile(cwd, fp, target, stack)
+		if err != nil {
+			s = fmt.Sprintf("<div class=\"include-error\">include <code>%s</code>: %s</div>\n",
+				html.EscapeString(target), html.EscapeString(err.Error()))
+		}
+		out.WriteString("\n" + s + "\n")
+	}
+	return []byte(out.String())
+}
+
+func includeFile(cwd, from, target string, stack []string) (string, error) {
+	name, frag := target, ""
+	if i := strings.Index(target, "#"); i >= 0 {
+		name, frag = target[:i], target[i+1:]
+	}
+
+	var fp string
+	if strings.HasPrefix(name, "/") {
+		fp = filepath.Join(cwd, filepath.FromSlash(name))
+	} else {
+		fp = filepath.Join(filepath.Dir(from), filepath.FromSlash(name))
+	}
+
+	// 公開ディレクトリの外は読まない
+	real := realPath(fp)
+	if !insideRoot(cwd, real) {
+		return "", fmt.Errorf("outside of the served root")
+	}
+	for i, p := range stack {
+		if p == real {
+			chain := []string{}
+			for _, s := range append(stack[i:], real) {
+				rd, _ := filepath.Rel(realPath(cwd), s)
+				chain = append(chain, filepath.ToSlash(rd))
+			}
+			return "", fmt.Errorf("include cycle: %s", strings.Join(chain, " -> "))
+		}
+	}
+	if len(stack) > includeMaxDepth {
+		return "", fmt.Errorf("includes nested too deeply")
+	}
+
+	b, err := ioutil.ReadFile(fp)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("file not found")
+		}
+		return "", err
+	}
+	if frag != "" {
+		if b, err = lineRange(b, frag); err != nil {
+			return "", err
+		}
+	}
+
+	ext := strings.ToLower(filepath.Ext(fp))
+	if Match(`^\.(md|markdown|mkd)$`, ext) {
+		_, body, _ := FrontMatter(b)
+		return string(expandIncludes(cwd, fp, body, append(stack, real))), nil
+	}
+
+	lang := strings.TrimPrefix(ext, ".")
+	if lang == "" {
+		lang = strings.ToLower(filepath.Base(fp))
+	}
+	s := strings.TrimRight(string(b), "\n")
+	fence := "```"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + s + "\n" + fence + "\n", nil
+}
+
+// lineRange cut L10-L40 / L10 from b
+func lineRange(b []byte, frag string) ([]byte, error) {
+	m := includeRangeRe.FindStringSubmatch(frag)
+	if m == nil {
+		return nil, fmt.Errorf("bad line range %q", frag)
+	}
+	from, _ := strconv.Atoi(m[1])
+	to := from
+	if m[2] != "" {
+		to, _ = strconv.Atoi(m[2])
+	}
+
+	lines := strings.SplitAfter(strings.TrimSuffix(string(b), "\n"), "\n")
+	if from < 1 || to < from || from > len(lines) {
+		return nil, fmt.Errorf("line range %q out of file (%d lines)", frag, len(lines))
+	}
+	if to > len(lines) {
+		to = len(lines)
+	}
+	return []byte(strings.Join(lines[from-1:to], "")), nil
+}
+
+// insideRoot fp is under the served root cwd (symlinks resolved)
+func insideRoot(cwd, fp string) bool {
+	rel, err := filepath.Rel(realPath(cwd), realPath(fp))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// realPath absolute path with symlinks resolved
+func realPath(fp string) string {
+	if p, err := filepath.EvalSymlinks(fp); err == nil {
+		fp = p
+	}
+	if p, err := filepath.Abs(fp); err == nil {
+		fp = p
+	}
+	return fp
+}
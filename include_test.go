@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandIncludes(t *testing.T) {
+	top := t.TempDir()
+	cwd := filepath.Join(top, "root")
+	files := map[string]string{
+		"secret.txt":         "secret\n",
+		"root/part.md":       "---\ntitle: part\n---\npart body\n",
+		"root/x.md":          "x\n<!-- include: y.md -->\n",
+		"root/y.md":          "y\n<!-- include: x.md -->\n",
+		"root/self.md":       "<!-- include: self.md -->\n",
+		"root/code.go":       "package main\n\nfunc main() {\n}\n",
+		"root/fence.md.txt":  "```\ninner\n```\n",
+		"root/sub/nested.md": "{{< include \"/part.md\" >}}\n",
+	}
+	for name, s := range files {
+		fp := filepath.Join(top, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(fp), 0755)
+		if err := ioutil.WriteFile(fp, []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(top, "secret.txt"), filepath.Join(cwd, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		from string
+		src  string
+		want string
+	}{
+		{"a.md", "<!-- include: part.md -->", "\npart body\n"},
+		{"a.md", `{{< include "part.md" >}}`, "\npart body\n"},
+		{"sub/a.md", "<!-- include: nested.md -->", "\npart body\n"},
+		{"sub/a.md", "<!-- include: ../part.md -->", "\npart body\n"},
+		{"a.md", "<!-- include: code.go#L3-L4 -->", "```go\nfunc main() {\n}\n```\n"},
+		{"a.md", "<!-- include: code.go#L1 -->", "```go\npackage main\n```\n"},
+		{"a.md", "<!-- include: code.go#L9 -->", `line range &#34;L9&#34; out of file (4 lines)`},
+		{"a.md", "<!-- include: fence.md.txt -->", "````txt\n```\ninner\n```\n````\n"},
+		{"a.md", "<!-- include: missing.md -->", "<code>missing.md</code>: file not found"},
+		// 循環と公開ディレクトリの外
+		{"a.md", "<!-- include: x.md -->", "include cycle: x.md -&gt; y.md -&gt; x.md"},
+		{"self.md", "<!-- include: self.md -->", "include cycle: self.md -&gt; self.md"},
+		{"a.md", "<!-- include: ../secret.txt -->", "<code>../secret.txt</code>: outside of the served root"},
+		{"a.md", "<!-- include: /../secret.txt -->", "outside of the served root"},
+		{"a.md", "<!-- include: link.txt -->", "<code>link.txt</code>: outside of the served root"},
+		// コードの中はそのまま
+		{"a.md", "```\n<!-- include: part.md -->\n```", "```\n<!-- include: part.md -->\n```"},
+		{"a.md", "text <!-- include: part.md -->", "text <!-- include: part.md -->"},
+	}
+	for _, tt := range tests {
+		got := string(ExpandIncludes(cwd, filepath.Join(cwd, filepath.FromSlash(tt.from)), []byte(tt.src)))
+		if !strings.Contains(got, tt.want) {
+			t.Errorf("%s %q:\n got %q\nwant %q", tt.from, tt.src, got, tt.want)
+		}
+		if strings.Contains(got, "secret\n") {
+			t.Errorf("%s %q: read a file outside the root", tt.from, tt.src)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// largeFileSize larger text files are paged by lines
+	largeFileSize = 1 << 20
+	// largePageLines most lines per page
+	largePageLines = 1000
+	// largePageBytes most bytes per page, a longer line goes on over pages
+	largePageBytes = 256 << 10
+)
+
+// lineIndex where the pages of a file start
+type lineIndex struct {
+	Size    int64
+	ModTime time.Time
+	Newline int64   // 改行の数
+	LastEnd int64   // 最後の改行の直後
+	Offsets []int64 // ページの先頭
+	Firsts  []int   // ページの先頭の行番号 (長い行の続きならその行)
+}
+
+var (
+	lineIndexMu    sync.Mutex
+	lineIndexCache = map[string]*lineIndex{}
+)
+
+// Lines number of lines, a last line without newline counts
+func (ix *lineIndex) Lines() int {
+	n := int(ix.Newline)
+	if ix.Size > ix.LastEnd {
+		n++
+	}
+	return n
+}
+
+// Pages number of pages, at least 1
+func (ix *lineIndex) Pages() int {
+	n := len(ix.Offsets)
+	// 末尾がちょうど区切りなら空のページは数えない
+	if n > 1 && ix.Offsets[n-1] >= ix.Size {
+		n--
+	}
+	return n
+}
+
+// PageOf page (from 1) where line starts
+func (ix *lineIndex) PageOf(line int) int {
+	n := ix.Pages()
+	i := sort.Search(n, func(i int) bool { return ix.Firsts[i] >= line })
+	if i == n || ix.Firsts[i] > line {
+		i--
+	}
+	return i + 1
+}
+
+// cut start new pages until the last one is no longer than largePageBytes
+// up to end
+func (ix *lineIndex) cut(end int64) {
+	for {
+		start := ix.Offsets[len(ix.Offsets)-1]
+		if end-start <= largePageBytes {
+			return
+		}
+		if ix.LastEnd > start {
+			// 前の行の終わりで区切る
+			ix.Offsets = append(ix.Offsets, ix.LastEnd)
+			ix.Firsts = append(ix.Firsts, int(ix.Newline)+1)
+		} else {
+			// 1 行で溢れるので行の途中で区切る
+			ix.Offsets = append(ix.Offsets, start+largePageBytes)
+			ix.Firsts = append(ix.Firsts, ix.Firsts[len(ix.Firsts)-1])
+		}
+	}
+}
+
+// LineIndex index of fp, a grown file (log) is scanned from the old end only
+func LineIndex(fp string, info os.FileInfo) (*lineIndex, error) {
+	lineIndexMu.Lock()
+	old := lineIndexCache[fp]
+	lineIndexMu.Unlock()
+	if old != nil && old.Size == info.Size() && old.ModTime.Equal(info.ModTime()) {
+		return old, nil
+	}
+
+	ix := &lineIndex{Offsets: []int64{0}, Firsts: []int{1}}
+	if old != nil && old.Size <= info.Size() && !old.ModTime.After(info.ModTime()) {
+		*ix = *old
+		ix.Offsets = append([]int64{}, old.Offsets...)
+		ix.Firsts = append([]int{}, old.Firsts...)
+	}
+	f, err := os.Open(fp)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(ix.Size, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	rd := bufio.NewReaderSize(io.LimitReader(f, info.Size()-ix.Size), 64<<10)
+	buf := make([]byte, 64<<10)
+	off := ix.Size
+	for {
+		n, err := rd.Read(buf)
+		b := buf[:n]
+		for {
+			i := bytes.IndexByte(b, '\n')
+			if i < 0 {
+				break
+			}
+			end := off + int64(i) + 1
+			ix.cut(end)
+			ix.Newline++
+			ix.LastEnd = end
+			if int(ix.Newline)-ix.Firsts[len(ix.Firsts)-1]+1 >= largePageLines {
+				ix.Offsets = append(ix.Offsets, end)
+				ix.Firsts = append(ix.Firsts, int(ix.Newline)+1)
+			}
+			off = end
+			b = b[i+1:]
+		}
+		off += int64(len(b))
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	// 改行のない最後の行
+	ix.cut(off)
+	ix.Size, ix.ModTime = off, info.ModTime()
+
+	lineIndexMu.Lock()
+	lineIndexCache[fp] = ix
+	lineIndexMu.Unlock()
+	return ix, nil
+}
+
+// LargeFilePage text of page pageNo (from 1) and its first line number
+func LargeFilePage(fp string, ix *lineIndex, pageNo int) (string, int, error) {
+	start := ix.Offsets[pageNo-1]
+	end := ix.Size
+	if pageNo < len(ix.Offsets) {
+		end = ix.Offsets[pageNo]
+	}
+
+	f, err := os.Open(fp)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	b := make([]byte, end-start)
+	n, err := f.ReadAt(b, start)
+	if err != nil && err != io.EOF {
+		return "", 0, err
+	}
+	return string(bytes.TrimSuffix(b[:n], []byte("\n"))), ix.Firsts[pageNo-1], nil
+}
+
+// largeFileView page of a large text file for fileview, ?line=N jumps to the
+// page of line N, ?chunk=1 returns only the code for infinite scroll; done
+// when the response is already written
+func largeFileView(name, fp string, info os.FileInfo, w http.ResponseWriter, r *http.Request) (code string, done bool) {
+	ix, err := LineIndex(fp, info)
+	if err != nil {
+		return extRenderError("index", err), false
+	}
+	lines, pages := ix.Lines(), ix.Pages()
+
+	if line, err := strconv.Atoi(r.FormValue("line")); err == nil {
+		if line < 1 {
+			line = 1
+		}
+		if line > lines {
+			line = lines
+		}
+		q := url.Values{"page": {strconv.Itoa(ix.PageOf(line))}}
+		if v := r.FormValue("view"); v != "" {
+			q.Set("view", v)
+		}
+		u := url.URL{Path: name, RawQuery: q.Encode(), Fragment: fmt.Sprintf("L%d", line)}
+		http.Redirect(w, r, u.String(), http.StatusFound)
+		return "", true
+	}
+	pageNo, _ := strconv.Atoi(r.FormValue("page"))
+	if pageNo < 1 || pageNo > pages {
+		pageNo = 1
+	}
+	// ?view=source のまま次のページへ
+	params := url.Values{}
+	hidden := ""
+	if v := r.FormValue("view"); v != "" {
+		params.Set("view", v)
+		hidden = fmt.Sprintf("<input type=\"hidden\" name=\"view\" value=\"%s\">", html.EscapeString(v))
+	}
+	next := ""
+	if pageNo < pages {
+		nv := url.Values{"page": {strconv.Itoa(pageNo + 1)}, "chunk": {"1"}}
+		for k, v := range params {
+			nv[k] = v
+		}
+		next = fmt.Sprintf("<div class=\"big-next\" data-next=\"?%s\"></div>\n", html.EscapeString(nv.Encode()))
+	}
+
+	src, first, err := LargeFilePage(fp, ix, pageNo)
+	if err != nil {
+		return extRenderError("read", err), false
+	}
+	hl := HighlightFrom(src, name, first)
+	if pageNo > 1 && ix.Firsts[pageNo-2] == first {
+		// 長い行の続きなので id は行の始まりのページに任せる
+		hl = strings.Replace(hl, fmt.Sprintf(" id=\"L%d\"", first), "", 1)
+	}
+	var buf bytes.Buffer
+	buf.WriteString(hl)
+	if r.FormValue("chunk") != "" {
+		buf.WriteString(next)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		buf.WriteTo(w)
+		return "", true
+	}
+
+	pager := pagerLinks(name, params, pageNo, pages, fmt.Sprintf("%d lines", lines))
+	head := fmt.Sprintf("<form class=\"big-jump\" method=\"get\" action=\"%s\">%s, %d lines %s<input type=\"number\" name=\"line\" min=\"1\" max=\"%d\" placeholder=\"line\"><input type=\"submit\" value=\"jump\"></form>\n",
+		html.EscapeString((&url.URL{Path: name}).String()), ByteSize(info.Size()), lines, hidden, lines)
+	return head + pager + "<div class=\"big-file\">\n" + buf.String() + next + "</div>\n" + pager, false
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func numberedLines(from, to int) string {
+	var b strings.Builder
+	for i := from; i <= to; i++ {
+		b.WriteString(strings.Repeat("x", i%7))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func writeIndexed(t *testing.T, fp, s string) *lineIndex {
+	t.Helper()
+	if err := ioutil.WriteFile(fp, []byte(s), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix, err := LineIndex(fp, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ix
+}
+
+func TestLineIndex(t *testing.T) {
+	long := strings.Repeat("y", 600<<10)
+	tests := []struct {
+		name   string
+		src    string
+		lines  int
+		firsts []int
+	}{
+		{"empty", "", 0, []int{1}},
+		{"no newline", "abc", 1, []int{1}},
+		{"1000 lines", numberedLines(1, 1000), 1000, []int{1}},
+		{"1001 lines", numberedLines(1, 1001), 1001, []int{1, 1001}},
+		{"last line open", strings.TrimSuffix(numberedLines(1, 2500), "\n"), 2500, []int{1, 1001, 2001}},
+		// 256 KiB を超える前の行末で区切る
+		{"1 KiB lines", strings.Repeat(strings.Repeat("z", 1023)+"\n", 300), 300, []int{1, 257}},
+		// 長い行は行の途中で区切り, 続きのページも同じ行から
+		{"long line", long + "\na\n", 2, []int{1, 1, 1}},
+	}
+	dir := t.TempDir()
+	for _, tt := range tests {
+		fp := filepath.Join(dir, strings.Replace(tt.name, " ", "_", -1)+".txt")
+		ix := writeIndexed(t, fp, tt.src)
+		if ix.Lines() != tt.lines {
+			t.Errorf("%s: Lines() = %d, want %d", tt.name, ix.Lines(), tt.lines)
+		}
+		if got := ix.Firsts[:ix.Pages()]; !reflect.DeepEqual(got, tt.firsts) {
+			t.Errorf("%s: firsts %v, want %v", tt.name, got, tt.firsts)
+		}
+
+		// ページをつなげると元のファイル, どのページも上限以内
+		var all strings.Builder
+		for p := 1; p <= ix.Pages(); p++ {
+			start, end := ix.Offsets[p-1], ix.Size
+			if p < len(ix.Offsets) {
+				end = ix.Offsets[p]
+			}
+			page := tt.src[start:end]
+			if len(page) > largePageBytes || strings.Count(page, "\n") > largePageLines {
+				t.Errorf("%s: page %d has %d bytes, %d lines", tt.name, p, len(page), strings.Count(page, "\n"))
+			}
+			all.WriteString(page)
+		}
+		if all.String() != tt.src {
+			t.Errorf("%s: pages do not add up to the file", tt.name)
+		}
+	}
+}
+
+func TestLineIndexPageOf(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "a.txt")
+	ix := writeIndexed(t, fp, strings.Repeat("y", 600<<10)+"\n"+numberedLines(2, 2500))
+	tests := []struct{ line, page int }{
+		{1, 1}, {2, 3}, {1000, 3}, {1001, 4}, {2000, 4}, {2001, 5}, {2500, 5},
+	}
+	for _, tt := range tests {
+		if got := ix.PageOf(tt.line); got != tt.page {
+			t.Errorf("PageOf(%d) = %d, want %d (firsts %v)", tt.line, got, tt.page, ix.Firsts)
+		}
+	}
+}
+
+func TestLineIndexGrow(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "a.log")
+	src := numberedLines(1, 1500) + "partial"
+	writeIndexed(t, fp, src)
+
+	// ログが伸びたら続きから
+	src += " line\n" + strings.Repeat("w", 300<<10) + "\n" + numberedLines(1, 1200)
+	f, err := os.OpenFile(fp, os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(src)
+	f.Close()
+	later := time.Now().Add(time.Second)
+	os.Chtimes(fp, later, later)
+	info, _ := os.Stat(fp)
+	grown, err := LineIndex(fp, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lineIndexMu.Lock()
+	delete(lineIndexCache, fp)
+	lineIndexMu.Unlock()
+	fresh, err := LineIndex(fp, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(grown, fresh) {
+		t.Errorf("grown index\n%+v\nwant\n%+v", grown, fresh)
+	}
+
+	// 切り詰められたら作り直す
+	ix := writeIndexed(t, fp, "a\nb\n")
+	if ix.Lines() != 2 || ix.Pages() != 1 {
+		t.Errorf("truncated: %d lines, %d pages", ix.Lines(), ix.Pages())
+	}
+}
+
+func TestLargeFileView(t *testing.T) {
+	cwd := t.TempDir()
+	fp := filepath.Join(cwd, "big.txt")
+	writeIndexed(t, fp, strings.Repeat("y", 600<<10)+"\n"+numberedLines(2, 2500))
+	info, _ := os.Stat(fp)
+
+	view := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		code, done := largeFileView("/big.txt", fp, info, w, httptest.NewRequest("GET", "/big.txt?"+query, nil))
+		if !done {
+			w.WriteString(code)
+		}
+		return w
+	}
+
+	redirects := []struct{ query, location string }{
+		{"line=1500", "/big.txt?page=4#L1500"},
+		{"line=1500&view=source", "/big.txt?page=4&view=source#L1500"},
+		{"line=2", "/big.txt?page=3#L2"},
+		{"line=0", "/big.txt?page=1#L1"},
+		{"line=99999", "/big.txt?page=5#L2500"},
+	}
+	for _, tt := range redirects {
+		w := view(tt.query)
+		if w.Code != 302 || w.Header().Get("Location") != tt.location {
+			t.Errorf("?%s: %d %q, want 302 %q", tt.query, w.Code, w.Header().Get("Location"), tt.location)
+		}
+	}
+
+	// 長い行の続きのページには L1 の id を付けない
+	if s := view("page=2").Body.String(); strings.Contains(s, `id="L1"`) || !strings.Contains(s, "big-next") {
+		t.Errorf("continuation page: L1 id or next link wrong")
+	}
+	if s := view("page=1").Body.String(); !strings.Contains(s, `id="L1"`) {
+		t.Errorf("first page has no L1 id")
+	}
+	// 範囲外のページは最初のページ
+	if s := view("page=99").Body.String(); !strings.Contains(s, `id="L1"`) {
+		t.Errorf("page 99 does not fall back to page 1")
+	}
+	s := view("page=5&chunk=1").Body.String()
+	if !strings.Contains(s, `id="L2500"`) || strings.Contains(s, "big-next") || strings.Contains(s, "big-jump") {
+		t.Errorf("last chunk: %.200q", s)
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+// literateSyntax comment markers of a language, Start/End for block comments
+type literateSyntax struct {
+	Line       string
+	Start, End string
+}
+
+var (
+	slashComments = literateSyntax{"//", "/*", "*/"}
+	hashComments  = literateSyntax{"#", "", ""}
+
+	literateSyntaxes = map[string]literateSyntax{
+		".go":   slashComments,
+		".js":   slashComments,
+		".mjs":  slashComments,
+		".cjs":  slashComments,
+		".jsx":  slashComments,
+		".ts":   slashComments,
+		".tsx":  slashComments,
+		".c":    slashComments,
+		".h":    slashComments,
+		".cc":   slashComments,
+		".cpp":  slashComments,
+		".java": slashComments,
+		".rs":   slashComments,
+		".py":   hashComments,
+		".sh":   hashComments,
+		".bash": hashComments,
+		".zsh":  hashComments,
+		".rb":   hashComments,
+		".pl":   hashComments,
+	}
+)
+
+// literateSection comment text and the code after it
+type literateSection struct {
+	Docs []string
+	Code []string
+}
+
+// LiterateSyntax comment syntax for file name, false when not supported
+func LiterateSyntax(name string) (literateSyntax, bool) {
+	syn, ok := literateSyntaxes[strings.ToLower(filepath.Ext(name))]
+	return syn, ok
+}
+
+// LiterateSections split source at comment runs (docco style)
+func LiterateSections(name, src string, syn literateSyntax) []literateSection {
+	src = strings.TrimRight(src, "\n")
+	comments := commentLines(name, src)
+	sections := []literateSection{{}}
+	inBlock := false
+	for i, line := range strings.Split(src, "\n") {
+		cur := &sections[len(sections)-1]
+		t := strings.TrimSpace(line)
+
+		doc, ok := "", false
+		switch {
+		case comments != nil && !comments[i]:
+			// 文字列の中など
+		case inBlock:
+			if n := strings.Index(t, syn.End); n >= 0 {
+				inBlock = false
+				t = t[:n]
+			}
+			doc, ok = strings.TrimPrefix(strings.TrimPrefix(t, "*"), " "), true
+		case i == 0 && strings.HasPrefix(t, "#!"):
+		case strings.HasPrefix(t, "//go:") || strings.HasPrefix(t, "// +build"):
+			// ビルド指示はコード
+		case syn.Line != "" && strings.HasPrefix(t, syn.Line):
+			doc, ok = strings.TrimPrefix(t[len(syn.Line):], " "), true
+		case syn.Start != "" && strings.HasPrefix(t, syn.Start):
+			body := t[len(syn.Start):]
+			if n := strings.Index(body, syn.End); n >= 0 {
+				// 行の途中で閉じてその後ろにコードがあればコード
+				if strings.TrimSpace(body[n+len(syn.End):]) != "" {
+					break
+				}
+				body = body[:n]
+			} else {
+				inBlock = true
+			}
+			doc, ok = strings.TrimSpace(strings.TrimLeft(body, "*")), true
+		}
+
+		if !ok {
+			cur.Code = append(cur.Code, line)
+			continue
+		}
+		if len(cur.Code) > 0 {
+			sections = append(sections, literateSection{})
+			cur = &sections[len(sections)-1]
+		}
+		cur.Docs = append(cur.Docs, doc)
+	}
+	return sections
+}
+
+// commentLines lines holding only comments by the lexer of name, nil when
+// there is no lexer
+func commentLines(name, src string) map[int]bool {
+	lexer := lexers.Match(filepath.Base(name))
+	if lexer == nil {
+		return nil
+	}
+	it, err := lexer.Tokenise(nil, src)
+	if err != nil {
+		return nil
+	}
+	comment, code := map[int]bool{}, map[int]bool{}
+	line := 0
+	for _, tok := range it.Tokens() {
+		isComment := tok.Type.InCategory(chroma.Comment) &&
+			tok.Type != chroma.CommentPreproc && tok.Type != chroma.CommentHashbang
+		for i, part := range strings.Split(tok.Value, "\n") {
+			if i > 0 {
+				line++
+			}
+			if strings.TrimSpace(part) == "" {
+				continue
+			}
+			if isComment {
+				comment[line] = true
+			} else {
+				code[line] = true
+			}
+		}
+	}
+	for l := range code {
+		delete(comment, l)
+	}
+	return comment
+}
+
+// LiterateTabs code / literate tabs of fileview
+func LiterateTabs(literate bool) string {
+	active := [2]string{"active", ""}
+	if literate {
+		active = [2]string{"", "active"}
+	}
+	return fmt.Sprintf("<ul class=\"sheet-tabs\">\n<li class=\"%s\"><a href=\"?\">code</a></li>\n<li class=\"%s\"><a href=\"?view=literate\">literate</a></li>\n</ul>\n", active[0], active[1])
+}
+
+// Literate side-by-side html, comments as markdown and highlighted code
+func Literate(cwd, name, src string, syn literateSyntax, pg *page) string {
+	sections := LiterateSections(name, src, syn)
+
+	// 文脈が切れないようにコードはまとめてハイライトして行で分ける
+	all := []string{}
+	for _, s := range sections {
+		all = append(all, s.Code...)
+	}
+	lines := highlightLines(strings.Join(all, "\n"), name, len(all))
+
+	var buf bytes.Buffer
+	buf.WriteString("<div class=\"literate\">\n")
+	n := 0
+	for _, s := range sections {
+		from, to := 0, len(s.Code)
+		for from < to && strings.TrimSpace(s.Code[from]) == "" {
+			from++
+		}
+		for to > from && strings.TrimSpace(s.Code[to-1]) == "" {
+			to--
+		}
+		buf.WriteString("<div class=\"lit-section\">\n<div class=\"lit-docs\">\n")
+		if len(s.Docs) > 0 {
+			buf.Write(RenderMarkdown(cwd, name, []byte(strings.Join(s.Docs, "\n")), nil, pg))
+		}
+		buf.WriteString("</div>\n<div class=\"lit-code\">\n")
+		if from < to {
+			if lines != nil {
+				buf.WriteString("<pre class=\"chroma\"><code>")
+				for _, l := range lines[n+from : n+to] {
+					buf.WriteString(l)
+				}
+				buf.WriteString("</code></pre>\n")
+			} else {
+				buf.WriteString(Highlight(strings.Join(s.Code[from:to], "\n"), "", name, false))
+			}
+		}
+		buf.WriteString("</div>\n</div>\n")
+		n += len(s.Code)
+	}
+	buf.WriteString("</div>\n")
+	return buf.String()
+}
+
+// highlightLines highlighted html of each line, nil when it can not be split
+func highlightLines(src, name string, count int) []string {
+	h := Highlight(src, "", name, false)
+	i, j := strings.Index(h, "<code>"), strings.LastIndex(h, "</code>")
+	if i < 0 || j < i {
+		return nil
+	}
+	const marker = `<span class="line">`
+	parts := strings.Split(h[i+len("<code>"):j], marker)
+	if len(parts) != count+1 || parts[0] != "" {
+		return nil
+	}
+	lines := parts[1:]
+	for i := range lines {
+		lines[i] = marker + lines[i]
+	}
+	return lines
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// lrProtocol livereload protocol spoken with livereload.js
+const lrProtocol = "http://livereload.com/protocols/official-7"
+
+// lrMessage livereload command (hello, reload, info)
+type lrMessage struct {
+	Command    string   `json:"command"`
+	Protocols  []string `json:"protocols,omitempty"`
+	ServerName string   `json:"serverName,omitempty"`
+	Path       string   `json:"path,omitempty"`
+	LiveCSS    bool     `json:"liveCSS,omitempty"`
+}
+
+// liveReload websocket server of the livereload protocol
+type liveReload struct {
+	name  string
+	mu    sync.Mutex
+	conns map[*websocket.Conn]bool
+}
+
+func newLiveReload(name string) *liveReload {
+	return &liveReload{name: name, conns: map[*websocket.Conn]bool{}}
+}
+
+func (lr *liveReload) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	websocket.Handler(lr.serve).ServeHTTP(w, r)
+}
+
+func (lr *liveReload) serve(ws *websocket.Conn) {
+	defer ws.Close()
+	var m lrMessage
+	if err := websocket.JSON.Receive(ws, &m); err != nil || m.Command != "hello" {
+		return
+	}
+	// hello より先に reload が届かないように
+	lr.mu.Lock()
+	err := websocket.JSON.Send(ws, lrMessage{Command: "hello", Protocols: []string{lrProtocol}, ServerName: lr.name})
+	if err == nil {
+		lr.conns[ws] = true
+	}
+	lr.mu.Unlock()
+	if err != nil {
+		return
+	}
+	defer func() {
+		lr.mu.Lock()
+		delete(lr.conns, ws)
+		lr.mu.Unlock()
+	}()
+
+	// info などは読み捨てて切断を待つ
+	for {
+		if err := websocket.JSON.Receive(ws, &m); err != nil {
+			return
+		}
+	}
+}
+
+// Reload tell every connected page that path changed
+func (lr *liveReload) Reload(path string, liveCSS bool) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	for ws := range lr.conns {
+		websocket.JSON.Send(ws, lrMessage{Command: "reload", Path: path, LiveCSS: liveCSS})
+	}
+}
+
+// Close disconnect all pages
+func (lr *liveReload) Close() {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	for ws := range lr.conns {
+		ws.Close()
+	}
+}
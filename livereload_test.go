@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestLiveReload(t *testing.T) {
+	lr := newLiveReload("mkup")
+	s := httptest.NewServer(lr)
+	defer s.Close()
+	defer lr.Close()
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(s.URL, "http")+"/livereload", "", s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	if err := websocket.JSON.Send(ws, lrMessage{Command: "hello", Protocols: []string{lrProtocol}}); err != nil {
+		t.Fatal(err)
+	}
+	var m lrMessage
+	if err := websocket.JSON.Receive(ws, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Command != "hello" || m.ServerName != "mkup" || len(m.Protocols) != 1 || m.Protocols[0] != lrProtocol {
+		t.Fatalf("hello = %+v", m)
+	}
+
+	lr.Reload("/a.md", true)
+	if err := websocket.JSON.Receive(ws, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Command != "reload" || m.Path != "/a.md" || !m.LiveCSS {
+		t.Errorf("reload = %+v", m)
+	}
+}
@@ -2,12 +2,12 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"flag"
 	"fmt"
 	"gopkg.in/fsnotify.v1"
 	"html"
 	"html/template"
-	"io"
 	"io/ioutil"
 	"log"
 	"mime"
@@ -20,8 +20,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/omeid/livereload"
-	"github.com/russross/blackfriday"
 	"golang.org/x/exp/utf8string"
 )
 
@@ -34,13 +32,211 @@ const (
 <title>{{.Title}}</title>
 <link rel="stylesheet" href="/_assets/sanitize.css" media="all">
 <link rel="stylesheet" href="/_assets/github-markdown.css" media="all">
-<link rel="stylesheet" href="/_assets/sons-of-obsidian.css" media="all">
+<link rel="stylesheet" href="/_theme.css" media="all">
 <link rel="stylesheet" href="/_assets/style.css" media="all">
 <script src="/_assets/jquery-2.1.1.min.js"></script>
-<script src="/_assets/prettify.min.js"></script>
+{{if .Math}}
+<link rel="stylesheet" href="/_assets/katex/katex.min.css" media="all">
+<script src="/_assets/katex/katex.min.js"></script>
+{{end}}
+{{if .Mermaid}}
+<script src="/_assets/mermaid.min.js"></script>
+{{end}}
 <script>
 $(function() {
-	$('pre>code').each(function() { $(this.parentNode).addClass('prettyprint') }); prettyPrint();
+	if (window.katex) {
+		$('.math').each(function() { katex.render($(this).text(), this, {displayMode: $(this).hasClass('display'), throwOnError: false}) });
+	}
+	if (window.mermaid) {
+		mermaid.initialize({startOnLoad: false});
+		mermaid.run({nodes: $('.mermaid').get()});
+	}
+{{if .Writable}}
+	var etag = {{.ETag}};
+	$('input.task-list-item-checkbox[data-line]').prop('disabled', false).on('change', function() {
+		var box = this;
+		$.ajax({
+			type: 'POST',
+			url: '/_task' + window.location.pathname,
+			data: {line: $(box).data('line'), checked: box.checked},
+			headers: {'If-Match': etag}
+		}).done(function(data, status, xhr) {
+			etag = xhr.getResponseHeader('ETag');
+		}).fail(function(xhr) {
+			box.checked = !box.checked;
+			alert(xhr.responseText);
+		});
+	});
+{{end}}
+	$('.data-table .data-filter').on('input', function() {
+		var q = $(this).val().toLowerCase();
+		$(this).closest('.data-table').find('tbody tr').each(function() {
+			$(this).toggle($(this).text().toLowerCase().indexOf(q) >= 0);
+		});
+	});
+	$('.data-table th').on('click', function() {
+		var th = $(this), i = th.index(), tbody = th.closest('table').children('tbody');
+		var asc = !th.hasClass('sort-asc');
+		th.siblings().addBack().removeClass('sort-asc sort-desc');
+		th.addClass(asc ? 'sort-asc' : 'sort-desc');
+		var rows = tbody.children('tr').get();
+		rows.sort(function(a, b) {
+			var x = $(a).children().eq(i).text(), y = $(b).children().eq(i).text();
+			var c = (x !== '' && y !== '' && isFinite(x) && isFinite(y)) ? x - y : x.localeCompare(y, undefined, {numeric: true});
+			return asc ? c : -c;
+		});
+		tbody.append(rows);
+	});
+	$('.data-view .data-toggle').on('click', function(e) {
+		e.preventDefault();
+		var tree = $('.data-view .data-tree').toggle().is(':visible');
+		$('.data-view .data-source').toggle(!tree);
+		$(this).text(tree ? 'source' : 'tree');
+	});
+	$('.data-view .dt-copy').on('click', function(e) {
+		e.preventDefault();
+		e.stopPropagation();
+		var path = $(this).data('path');
+		if (navigator.clipboard) {
+			navigator.clipboard.writeText(path);
+		} else {
+			window.prompt('JSON path', path);
+		}
+	});
+	$('.data-view .data-filter').on('input', function() {
+		var q = $(this).val().toLowerCase(), tree = $('.data-view .data-tree');
+		tree.find('li').toggle(q === '');
+		if (q === '') {
+			return;
+		}
+		tree.find('.dt-key, .dt-value').filter(function() {
+			return $(this).text().toLowerCase().indexOf(q) >= 0;
+		}).each(function() {
+			var li = $(this).closest('li');
+			li.show().find('li').show();
+			li.parents('li').show().children('details').prop('open', true);
+		});
+	});
+	// #L10-L25 の行を強調, shift-click で範囲選択してリンクをコピー
+	function lineRange() {
+		var m = /^#L(\d+)(?:-L(\d+))?$/.exec(window.location.hash);
+		if (!m) {
+			return null;
+		}
+		var a = +m[1], b = +(m[2] || m[1]);
+		return [Math.min(a, b), Math.max(a, b)];
+	}
+	function markLines(scroll) {
+		var r = lineRange();
+		$('.lntable .hl-line').removeClass('hl-line');
+		if (!r) {
+			return;
+		}
+		for (var i = r[0]; i <= r[1]; i++) {
+			var num = $(document.getElementById('L' + i));
+			num.addClass('hl-line').closest('tr').find('.line').eq(num.index()).addClass('hl-line');
+		}
+		var top = $(document.getElementById('L' + r[0]));
+		if (scroll && top.length) {
+			$('html, body').scrollTop(top.offset().top - 80);
+		}
+	}
+	if ($('.lntable .lnlinks').length) {
+		$(document).on('click', '.lntable .lnlinks', function(e) {
+			e.preventDefault();
+			var n = +$(this).parent().attr('id').substr(1), r = lineRange(), hash = '#L' + n;
+			if (e.shiftKey && r) {
+				var from = Math.min(r[0], n), to = Math.max(r[0], n);
+				hash = from === to ? '#L' + from : '#L' + from + '-L' + to;
+			}
+			history.replaceState(null, '', hash);
+			markLines(false);
+			if (e.shiftKey) {
+				window.getSelection().removeAllRanges();
+				if (navigator.clipboard) {
+					navigator.clipboard.writeText(window.location.href);
+				} else {
+					window.prompt('Permalink', window.location.href);
+				}
+			}
+		});
+		$(window).on('hashchange', function() { markLines(true); });
+		markLines(true);
+	}
+	// 大きいファイルは下までスクロールしたら次のページを足す
+	var loading = false;
+	$(window).on('scroll', function() {
+		var next = $('.big-file .big-next');
+		if (loading || !next.length || $(window).scrollTop() + $(window).height() < next.offset().top - 400) {
+			return;
+		}
+		loading = true;
+		$.get(next.data('next')).done(function(data) {
+			next.replaceWith(data);
+			$('.data-pager').hide();
+		}).always(function() {
+			loading = false;
+		});
+	});
+	// ログの tail
+	$('.tail-view').each(function() {
+		var view = $(this), out = view.find('.tail-lines code'), status = view.find('.tail-status');
+		var paused = false, held = [], filter = null;
+		function show(line) {
+			line.toggle(!filter || filter.test(line.text()));
+		}
+		function add(html) {
+			var line = $('<div class="tail-line"></div>').html(html);
+			show(line);
+			out.append(line);
+			var lines = out.children();
+			if (lines.length > 5000) {
+				lines.slice(0, lines.length - 5000).remove();
+			}
+		}
+		function follow() {
+			if (view.find('.tail-follow').prop('checked')) {
+				$('html, body').scrollTop($(document).height());
+			}
+		}
+		var es = new EventSource(view.data('src'));
+		es.onopen = function() { status.text('following'); };
+		es.onerror = function() { status.text('disconnected, retrying'); };
+		es.onmessage = function(e) {
+			if (paused) {
+				held.push(e.data);
+				status.text(held.length + ' new lines');
+				return;
+			}
+			add(e.data);
+			follow();
+		};
+		es.addEventListener('reset', function() {
+			out.empty();
+			held = [];
+			status.text('file truncated');
+		});
+		view.find('.tail-pause').on('click', function() {
+			paused = !paused;
+			$(this).text(paused ? 'resume' : 'pause');
+			if (!paused) {
+				$.each(held, function(i, html) { add(html); });
+				held = [];
+				status.text('following');
+				follow();
+			}
+		});
+		view.find('.tail-filter').on('input', function() {
+			try {
+				filter = $(this).val() ? new RegExp($(this).val(), 'i') : null;
+				$(this).removeClass('tail-bad');
+			} catch (err) {
+				$(this).addClass('tail-bad');
+				return;
+			}
+			out.children().each(function() { show($(this)); });
+		});
+	});
 	$.getScript(window.location.protocol + '//' + window.location.hostname + ':35729/livereload.js');
 });
 </script>
@@ -65,6 +261,429 @@ $(function() {
 input {
      border: 1px solid #cccccc;
 }
+.toc {
+	 position: fixed;
+	 top: 20px;
+	 left: 20px;
+	 width: 220px;
+	 max-height: calc(100% - 40px);
+	 overflow: auto;
+	 font-size: 13px;
+}
+.toc ul {
+	 list-style: none;
+	 padding-left: 0;
+}
+.toc li {
+	 margin: 2px 0;
+}
+.toc .toc-h2 { padding-left: 1em; }
+.toc .toc-h3 { padding-left: 2em; }
+.toc .toc-h4, .toc .toc-h5, .toc .toc-h6 { padding-left: 3em; }
+.math.display {
+	 display: block;
+	 margin: 16px 0;
+	 text-align: center;
+	 overflow-x: auto;
+}
+.ext-render {
+	 margin-bottom: 16px;
+	 overflow-x: auto;
+}
+.ext-render-error, .include-error {
+	 color: #cc0000;
+	 border: 1px solid #cc0000;
+	 border-radius: 3px;
+	 padding: 4px 8px;
+	 margin-bottom: 4px;
+	 white-space: pre-wrap;
+}
+.markdown-body .task-list-item {
+	 list-style-type: none;
+}
+.markdown-body .task-list-item input {
+	 margin: 0 0.2em 0.25em -1.6em;
+	 vertical-align: middle;
+}
+.markdown-body .callout {
+	 margin-bottom: 16px;
+	 padding: 8px 16px;
+	 border-left: 4px solid #0969da;
+	 border-radius: 3px;
+	 background-color: #f6f8fa;
+}
+.markdown-body .callout > :last-child {
+	 margin-bottom: 0;
+}
+.markdown-body .callout-title {
+	 margin-bottom: 8px;
+	 font-weight: bold;
+	 color: #0969da;
+}
+.markdown-body summary.callout-title {
+	 cursor: pointer;
+}
+.markdown-body details.callout:not([open]) .callout-title {
+	 margin-bottom: 0;
+}
+.markdown-body .callout-tip { border-color: #1a7f37; }
+.markdown-body .callout-tip .callout-title { color: #1a7f37; }
+.markdown-body .callout-important { border-color: #8250df; }
+.markdown-body .callout-important .callout-title { color: #8250df; }
+.markdown-body .callout-warning { border-color: #9a6700; }
+.markdown-body .callout-warning .callout-title { color: #9a6700; }
+.markdown-body .callout-caution { border-color: #cf222e; }
+.markdown-body .callout-caution .callout-title { color: #cf222e; }
+.markdown-body .callout-abstract { border-color: #1b7c83; }
+.markdown-body .callout-abstract .callout-title { color: #1b7c83; }
+.markdown-body .callout-quote { border-color: #999999; }
+.markdown-body .callout-quote .callout-title { color: #666666; }
+.markdown-body .wikilink-missing {
+	 color: #cc0000;
+	 border-bottom: 1px dashed #cc0000;
+	 cursor: help;
+}
+.data-table {
+	 margin-bottom: 16px;
+	 overflow-x: auto;
+}
+.data-table .data-filter {
+	 margin-bottom: 8px;
+	 padding: 2px 4px;
+}
+.markdown-body .data-table table {
+	 margin-bottom: 8px;
+}
+.data-table th {
+	 cursor: pointer;
+	 white-space: nowrap;
+}
+.data-table th.sort-asc:after { content: " \25B2"; }
+.data-table th.sort-desc:after { content: " \25BC"; }
+.data-table td.num {
+	 text-align: right;
+}
+.data-pager {
+	 margin: 8px 0;
+	 color: #666666;
+}
+.sheet-tabs {
+	 list-style: none;
+	 padding-left: 0;
+	 border-bottom: 1px solid #dddddd;
+}
+.markdown-body .sheet-tabs li {
+	 display: inline-block;
+	 margin: 0 2px -1px 0;
+	 padding: 4px 12px;
+	 border: 1px solid #dddddd;
+	 border-radius: 3px 3px 0 0;
+	 background-color: #f6f8fa;
+}
+.markdown-body .sheet-tabs li.active {
+	 border-bottom-color: #ffffff;
+	 background-color: #ffffff;
+}
+.sheet-tabs .hidden-sheet a {
+	 color: #999999;
+}
+.sheet {
+	 overflow-x: auto;
+}
+.markdown-body .sheet table thead th, .markdown-body .sheet table tbody th {
+	 background-color: #f6f8fa;
+	 color: #666666;
+	 font-weight: normal;
+}
+.sheet td.num {
+	 text-align: right;
+}
+.data-tree, .data-tree ul {
+	 list-style: none;
+	 padding-left: 0;
+	 font-family: Consolas, "Liberation Mono", Menlo, monospace;
+	 font-size: 13px;
+}
+.markdown-body .data-tree ul {
+	 margin: 0;
+	 padding-left: 1.5em;
+}
+.markdown-body .data-tree li + li {
+	 margin-top: 0;
+}
+.data-tree summary {
+	 cursor: pointer;
+}
+.data-tree .dt-key { color: #0550ae; }
+.data-tree .dt-string { color: #0a3069; }
+.data-tree .dt-number, .data-tree .dt-boolean, .data-tree .dt-null { color: #cf222e; }
+.data-tree .dt-datetime { color: #8250df; }
+.data-tree .dt-count { color: #999999; }
+.data-tree .dt-badge {
+	 font-family: sans-serif;
+	 font-size: 10px;
+	 padding: 0 4px;
+	 border-radius: 3px;
+	 background-color: #eeeeee;
+	 color: #666666;
+}
+.data-tree .dt-copy {
+	 visibility: hidden;
+	 text-decoration: none;
+}
+.data-tree summary:hover > .dt-copy, .data-tree li.dt-leaf:hover > .dt-copy {
+	 visibility: visible;
+}
+.api-version {
+	 font-size: 12px;
+	 font-weight: normal;
+	 padding: 2px 6px;
+	 border-radius: 10px;
+	 background-color: #eeeeee;
+	 vertical-align: middle;
+}
+.api-op {
+	 margin-bottom: 16px;
+	 padding: 0 16px 1px 16px;
+	 border: 1px solid #61affe;
+	 border-radius: 4px;
+	 background-color: #f5faff;
+}
+.markdown-body .api-op h3 {
+	 margin-top: 12px;
+}
+.api-method {
+	 display: inline-block;
+	 min-width: 64px;
+	 padding: 2px 6px;
+	 border-radius: 3px;
+	 background-color: #61affe;
+	 color: #ffffff;
+	 font-size: 13px;
+	 text-align: center;
+}
+.api-post { border-color: #49cc90; background-color: #f4fbf7; }
+.api-post .api-method { background-color: #49cc90; }
+.api-put { border-color: #fca130; background-color: #fffaf3; }
+.api-put .api-method { background-color: #fca130; }
+.api-patch { border-color: #50e3c2; background-color: #f3fdfb; }
+.api-patch .api-method { background-color: #50e3c2; }
+.api-delete { border-color: #f93e3e; background-color: #fff5f5; }
+.api-delete .api-method { background-color: #f93e3e; }
+.api-head .api-method, .api-options .api-method, .api-trace .api-method { background-color: #9012fe; }
+.api-deprecated {
+	 opacity: 0.6;
+}
+.api-deprecated h3 code {
+	 text-decoration: line-through;
+}
+.api-section {
+	 font-weight: bold;
+	 margin-bottom: 8px;
+}
+.api-required {
+	 color: #cf222e;
+	 font-size: 11px;
+}
+.api-type {
+	 color: #8250df;
+	 font-family: Consolas, "Liberation Mono", Menlo, monospace;
+	 font-size: 12px;
+}
+.api-error {
+	 color: #cc0000;
+}
+.markdown-body .api-props {
+	 margin-bottom: 8px;
+}
+.api-props p {
+	 margin: 0;
+}
+.api-status {
+	 font-weight: bold;
+	 font-family: Consolas, "Liberation Mono", Menlo, monospace;
+}
+.api-status-2 { color: #1a7f37; }
+.api-status-3 { color: #0969da; }
+.api-status-4, .api-status-5 { color: #cf222e; }
+.api-media {
+	 margin-bottom: 4px;
+	 color: #666666;
+}
+.markdown-body .godoc-decl a {
+	 color: inherit;
+}
+.markdown-body .godoc-decl a:hover {
+	 color: #0366d6;
+}
+.godoc-index ul {
+	 list-style: none;
+}
+.godoc-example {
+	 margin-bottom: 16px;
+	 padding: 4px 12px;
+	 border: 1px solid #dddddd;
+	 border-radius: 3px;
+}
+.godoc-example summary {
+	 cursor: pointer;
+	 color: #0366d6;
+}
+.godoc-output {
+	 margin-bottom: 4px;
+	 font-weight: bold;
+}
+.markdown-body .lnlinks {
+	 color: inherit;
+	 text-decoration: none;
+}
+.chroma .line.hl-line, .chroma .lnt.hl-line {
+	 background-color: #fff8c5;
+}
+.chroma .lnt.hl-line {
+	 color: #24292e;
+}
+.media-view {
+	 margin-bottom: 16px;
+	 text-align: center;
+}
+.media-view img {
+	 max-width: 100%;
+	 background-image: linear-gradient(45deg, #eeeeee 25%, transparent 25%, transparent 75%, #eeeeee 75%),
+	   linear-gradient(45deg, #eeeeee 25%, transparent 25%, transparent 75%, #eeeeee 75%);
+	 background-position: 0 0, 8px 8px;
+	 background-size: 16px 16px;
+}
+.media-view video {
+	 max-width: 100%;
+}
+.media-view audio {
+	 width: 100%;
+}
+.media-view .media-pdf {
+	 width: 100%;
+	 height: 80vh;
+}
+.media-info {
+	 color: #666666;
+	 font-size: 12px;
+	 text-align: center;
+}
+.tail-tools {
+	 position: sticky;
+	 top: 0;
+	 padding: 4px 0;
+	 background-color: #ffffff;
+}
+.tail-status {
+	 color: #666666;
+	 font-size: 12px;
+}
+.tail-filter.tail-bad {
+	 border-color: #cc0000;
+	 color: #cc0000;
+}
+.markdown-body .tail-lines {
+	 white-space: pre-wrap;
+}
+.tail-line {
+	 min-height: 1.45em;
+}
+.big-jump {
+	 margin-bottom: 8px;
+	 color: #666666;
+}
+.big-jump input[type=number] {
+	 width: 8em;
+	 margin-left: 8px;
+}
+.markdown-body .big-file .lntable {
+	 margin-bottom: 0;
+}
+.markdown-body .bin-meta th {
+	 text-align: left;
+}
+.markdown-body .bin-meta code {
+	 word-break: break-all;
+}
+.hex-dump .hex-off {
+	 color: #999999;
+}
+.hex-dump .hex-ascii {
+	 color: #0366d6;
+}
+.literate {
+	 border-top: 1px solid #eeeeee;
+}
+.lit-section {
+	 display: flex;
+	 border-bottom: 1px solid #eeeeee;
+}
+.lit-docs {
+	 flex: 0 0 40%;
+	 min-width: 0;
+	 padding: 8px 16px 8px 0;
+}
+.lit-code {
+	 flex: 1 1 60%;
+	 min-width: 0;
+}
+.markdown-body .lit-code pre {
+	 margin: 0;
+	 border-radius: 0;
+}
+.nb-cell {
+	 margin-bottom: 16px;
+}
+.nb-prompt {
+	 color: #303f9f;
+	 font-family: monospace;
+	 font-size: 12px;
+}
+.nb-output {
+	 margin: 4px 0 0 0;
+	 overflow-x: auto;
+}
+.nb-output img {
+	 max-width: 100%;
+}
+.markdown-body .nb-output pre {
+	 background-color: transparent;
+	 border-left: 3px solid #eeeeee;
+}
+.markdown-body .nb-output .nb-stderr, .markdown-body .nb-output .nb-error {
+	 background-color: #fff0f0;
+}
+.meta {
+	 font-size: 12px;
+	 color: #666666;
+	 margin-bottom: 16px;
+}
+.meta table {
+	 margin: 0;
+}
+.meta th, .meta td {
+	 padding: 2px 8px;
+	 text-align: left;
+}
+.meta-error {
+	 color: #cc0000;
+}
+@media (max-width: 1460px) {
+	.toc { display: none; }
+}
+.markdown-body .anchor {
+	 float: left;
+	 margin-left: -20px;
+	 padding-right: 4px;
+	 color: #cccccc;
+	 text-decoration: none;
+	 visibility: hidden;
+}
+.markdown-body h1:hover .anchor, .markdown-body h2:hover .anchor, .markdown-body h3:hover .anchor,
+.markdown-body h4:hover .anchor, .markdown-body h5:hover .anchor, .markdown-body h6:hover .anchor {
+	 visibility: visible;
+}
 </style>
 </head>
 <body>
@@ -74,30 +693,65 @@ input {
 {{end}}
 <div class="right">
 <form action="{{.Spath}}" method="post">
-<p><input type="text" name="word" size="20"><input type="submit" value="検索"></p>
+<p><input type="text" name="word" size="20"><input type="submit" value="検索">
+<label><input type="checkbox" name="docs" value="1">文書のみ</label></p>
 </form>
 </div>
 </div>
+{{if .Toc}}
+<nav class="toc markdown-body">
+<h4>Contents</h4>
+<ul>
+{{range $var := .Toc}}
+ <li class="toc-h{{$var.Level}}"><a href="#{{$var.ID}}">{{$var.Text}}</a></li>
+{{end}}
+</ul>
+</nav>
+{{end}}
 <div class="container">
 <div class="markdown-body">
 {{if .Dirdisp}}
+{{if .GoPackage}}
+<ul class="sheet-tabs">
+<li class="active"><a href="?">files</a></li>
+<li><a href="?view=doc">package {{.GoPackage}}</a></li>
+</ul>
+{{end}}
 <h4>Directory</h4>
 <ul>
 {{range $var1 := .Dirs}}
  <li><a href="{{$var1}}">{{$var1 | basename}}/</a></li>
 {{end}}
 </ul>
-<h4>Markdown</h4>
+<h4>Documents</h4>
 <ul>
 {{range $var2 := .Files}}
  <li><a href="{{$var2}}">{{$var2 | basename}}</a></li>
 {{end}}
 </ul>
+{{if .Media}}
+<h4>Media</h4>
+<ul>
+{{range $var3 := .Media}}
+ <li><a href="{{$var3}}?view=media">{{$var3 | basename}}</a></li>
+{{end}}
+</ul>
+{{end}}
 {{end}}
 {{if .CodeFileDisp}}
-<pre><code>
-{{.CodeText}}
-</code><pre>
+{{.CodeHTML}}
+{{end}}
+{{if or .Meta .MetaError}}
+<div class="meta">
+{{if .MetaError}}<p class="meta-error">front matter: {{.MetaError}}</p>{{end}}
+{{if .Meta}}
+<table>
+{{range $var := .Meta}}
+ <tr><th>{{$var.Key}}</th><td>{{$var.Value}}</td></tr>
+{{end}}
+</table>
+{{end}}
+</div>
 {{end}}
 `
 	templatedown = `</div>
@@ -105,16 +759,17 @@ input {
 </body>
 </html>
 `
-	extensions = blackfriday.EXTENSION_NO_INTRA_EMPHASIS |
-		blackfriday.EXTENSION_TABLES |
-		blackfriday.EXTENSION_FENCED_CODE |
-		blackfriday.EXTENSION_AUTOLINK |
-		blackfriday.EXTENSION_STRIKETHROUGH |
-		blackfriday.EXTENSION_SPACE_HEADERS
 )
 
 var (
-	addr = flag.String("http", ":8000", "HTTP service address (e.g., ':8000')")
+	addr        = flag.String("http", ":8000", "HTTP service address (e.g., ':8000')")
+	configFile  = flag.String("config", ".mkup.toml", "project config file")
+	theme       = flag.String("theme", "github", "code highlight theme (e.g., 'github', 'monokai')")
+	lineNumbers = flag.Bool("linenos", false, "show line numbers in fenced code blocks")
+	extFlags    = flag.String("extensions", "", "markdown extensions (e.g., '+footnotes,-autolink')")
+	htmlFlags   = flag.String("html-flags", "", "markdown html renderer flags (e.g., '+smartypants')")
+	writable    = flag.Bool("writable", false, "allow toggling task list checkboxes (writes files)")
+	renderers   = flag.Bool("renderers", false, "run the external renderers of the config file (runs commands)")
 )
 
 type dirNest struct {
@@ -128,9 +783,18 @@ type page struct {
 	Dirnests     []dirNest
 	Dirdisp      bool
 	Dirs         []string
+	GoPackage    string
 	Files        []string
+	Media        []string
 	CodeFileDisp bool
-	CodeText     string
+	CodeHTML     template.HTML
+	Toc          []tocEntry
+	Meta         []metaEntry
+	MetaError    string
+	Math         bool
+	Mermaid      bool
+	Writable     bool
+	ETag         string
 }
 
 // String type string
@@ -175,32 +839,8 @@ func MenuDir(rd string, pg *page) {
 	}
 }
 
-func filepathRel(base, dir string) (rd string, err error) {
-	rd, err = filepath.Rel(base, dir)
-	if err != nil {
-		return
-	}
-	rd = ReplaceAll(`\\`, "/", rd)
-	log.Println(rd)
-	return
-}
-
-func fileview(cwd string, w http.ResponseWriter, r *http.Request) {
-	name := r.URL.Path
-	fp := filepath.Join(cwd, name)
-
-	pg := page{}
-	pg.Title = name + " - mkup"
-	pg.CodeFileDisp = true
-	dir := filepath.Dir(fp)
-
-	// 階層メニュー Dirnests
-	rd, _ := filepathRel(cwd, dir)
-	MenuDir(rd, &pg)
-
-	b, err := ioutil.ReadFile(filepath.Join(cwd, name))
-	pg.CodeText = string(b)
-
+// renderPage page header from the template, body and footer
+func renderPage(w http.ResponseWriter, pg page, body []byte) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
 	// tpl
@@ -216,70 +856,101 @@ func fileview(cwd string, w http.ResponseWriter, r *http.Request) {
 		panic(err)
 	}
 
-	fmt.Fprint(w, templatedown)
+	w.Write(body)
 
-	return
+	fmt.Fprint(w, templatedown)
 }
 
-func mdview(cwd string, w http.ResponseWriter, r *http.Request) {
-	name := r.URL.Path
-	b, err := ioutil.ReadFile(filepath.Join(cwd, name))
+func filepathRel(base, dir string) (rd string, err error) {
+	rd, err = filepath.Rel(base, dir)
 	if err != nil {
-		if os.IsNotExist(err) {
-			http.Error(w, "404 page not found", 404)
-			return
-		}
-		http.Error(w, err.Error(), 500)
 		return
 	}
+	rd = ReplaceAll(`\\`, "/", rd)
+	log.Println(rd)
+	return
+}
 
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+func fileview(cwd string, w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Path
+	fp := filepath.Join(cwd, name)
 
-	renderer := blackfriday.HtmlRenderer(0, "", "")
-	b = blackfriday.Markdown(b, renderer, extensions)
+	// バイナリはテキストにしない
+	if ctype, binary := SniffFile(fp); binary {
+		binaryview(cwd, ctype, w, r)
+		return
+	}
 
 	pg := page{}
-	pg.Title = filepath.Base(name) + " - mkup"
+	pg.Title = name + " - mkup"
+	pg.CodeFileDisp = true
+	dir := filepath.Dir(fp)
 
 	// 階層メニュー Dirnests
-	rd := filepath.Dir(name)
+	rd, _ := filepathRel(cwd, dir)
 	MenuDir(rd, &pg)
 
-	// tpl
-	funcMap := template.FuncMap{
-		"basename": filepath.Base,
-	}
-	tpl, err := template.New("foo").Funcs(funcMap).Parse(templateup)
-	if err != nil {
-		panic(err)
-	}
-	err = tpl.Execute(w, pg)
-	if err != nil {
-		panic(err)
+	// 大きいファイルはページ単位で読む
+	code := ""
+	if info, err := os.Stat(fp); err == nil && info.Size() > largeFileSize {
+		var done bool
+		if code, done = largeFileView(name, fp, info, w, r); done {
+			return
+		}
+	} else {
+		b, _ := ioutil.ReadFile(fp)
+		// コメントを Markdown にして横に並べる
+		syn, literate := LiterateSyntax(name)
+		switch {
+		case literate && r.FormValue("view") == "literate":
+			code = LiterateTabs(true) + Literate(cwd, name, string(b), syn, &pg)
+		case literate:
+			code = LiterateTabs(false) + HighlightFile(string(b), name)
+		default:
+			code = HighlightFile(string(b), name)
+		}
 	}
+	pg.CodeHTML = template.HTML(code)
 
-	w.Write(b)
+	renderPage(w, pg, nil)
 
-	fmt.Fprint(w, templatedown)
 	return
 }
 
-func imageview(cwd string, w http.ResponseWriter, r *http.Request) {
-	name := r.URL.Path
-	rfp, err := os.Open(filepath.Join(cwd, name))
+// markdownDoc markdown document with front matter and task lists
+func markdownDoc(cwd, name string, b []byte, pg *page) ([]byte, error) {
+	// front matter
+	src := b
+	meta, b, err := FrontMatter(b)
 	if err != nil {
-		http.Error(w, "404 page not found", 404)
-		return
+		pg.MetaError = err.Error()
 	}
-	defer rfp.Close()
-	io.Copy(w, rfp)
-	return
+	tasks := TaskLines(b, bytes.Count(src, []byte("\n"))-bytes.Count(b, []byte("\n")))
+
+	if title := MetaString(meta, "title"); title != "" {
+		pg.Title = title + " - mkup"
+	}
+	pg.Meta = MetaEntries(meta)
+
+	b = RenderMarkdown(cwd, name, b, meta, pg)
+	b = NumberTasks(b, tasks)
+	if info, err := os.Stat(filepath.Join(cwd, name)); err == nil && *writable {
+		pg.Writable = true
+		pg.ETag = FileETag(info)
+	}
+	return b, nil
 }
 
 func dirview(cwd string, w http.ResponseWriter, r *http.Request) {
 	name := r.URL.Path
 	dir := filepath.Join(cwd, name)
 
+	// Go パッケージのドキュメント
+	if r.FormValue("view") == "doc" {
+		godocview(cwd, w, r)
+		return
+	}
+
 	// index.md redirect
 	fim := filepath.Join(dir, "index.md")
 	_, err := os.Stat(fim)
@@ -292,6 +963,7 @@ func dirview(cwd string, w http.ResponseWriter, r *http.Request) {
 	pg := page{}
 	pg.Dirdisp = true
 	pg.Title = name + " - mkup"
+	pg.GoPackage = GoPackageName(dir)
 
 	// 階層メニュー Dirnests
 	rd, _ := filepathRel(cwd, dir)
@@ -317,28 +989,15 @@ func dirview(cwd string, w http.ResponseWriter, r *http.Request) {
 		if f.IsDir() {
 			pg.Dirs = append(pg.Dirs, "/"+fn)
 		} else {
-			if Match("\\.(md|markdown|mkd)$", fn) {
+			if IsDocument(fn) || ViewFor(fn) != nil {
 				pg.Files = append(pg.Files, "/"+fn)
+			} else if MediaKind(fn) != "" {
+				pg.Media = append(pg.Media, "/"+fn)
 			}
 		}
 	}
 
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-
-	// tpl
-	funcMap := template.FuncMap{
-		"basename": filepath.Base,
-	}
-	tpl, err := template.New("foo").Funcs(funcMap).Parse(templateup)
-	if err != nil {
-		panic(err)
-	}
-	err = tpl.Execute(w, pg)
-	if err != nil {
-		panic(err)
-	}
-
-	fmt.Fprint(w, templatedown)
+	renderPage(w, pg, nil)
 
 	return
 }
@@ -362,31 +1021,19 @@ func search(cwd string, w http.ResponseWriter, r *http.Request) {
 	rd, _ := filepathRel(cwd, name)
 	MenuDir(rd, &pg)
 
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-
-	// tpl
-	funcMap := template.FuncMap{
-		"basename": filepath.Base,
-	}
-	tpl, err := template.New("foo").Funcs(funcMap).Parse(templateup)
-	if err != nil {
-		panic(err)
-	}
-	err = tpl.Execute(w, pg)
-	if err != nil {
-		panic(err)
-	}
-
 	// fmt.Fprintf(w, "search %s  word→%s", name, word)
 	path, err := exec.LookPath("ag")
 
-	cmd := &exec.Cmd{}
+	args := []string{}
 	asci := utf8string.NewString(word)
 	if asci.IsASCII() {
-		cmd = exec.Command(path, "-i", word, name)
-	} else {
-		cmd = exec.Command(path, word, name)
+		args = append(args, "-i")
+	}
+	// 文書 (md, org, ...) だけ検索
+	if r.Form.Get("docs") != "" {
+		args = append(args, "-G", DocumentPattern())
 	}
+	cmd := exec.Command(path, append(args, word, name)...)
 	// fmt.Printf("%s -i %s %s\n", path, word, name)
 
 	stdout, err := cmd.StdoutPipe()
@@ -400,7 +1047,8 @@ func search(cwd string, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fmt.Fprintf(w, "<h2>%s の検索結果</h2><br />\n", word)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<h2>%s の検索結果</h2><br />\n", word)
 
 	top := cwd
 	top = ReplaceAll(`^[a-zA-Z]:`, "", top) // windows c: ...cut
@@ -418,23 +1066,21 @@ func search(cwd string, w http.ResponseWriter, r *http.Request) {
 		if err == nil {
 			if b != f {
 				b = f
-				fmt.Fprintf(w, "<a href=\"/%s\">%s</a><br />\n", f, f)
+				fmt.Fprintf(&buf, "<a href=\"/%s\">%s</a><br />\n", f, f)
 			}
 			t := strings.Join(pr[2:], ":")
-			fmt.Fprintf(w, "　%v : %s<br />", pr[1], html.EscapeString(t))
+			fmt.Fprintf(&buf, "　<a href=\"%s\">%v</a> : %s<br />", html.EscapeString(SourceLink(cwd, f, pr[1])), pr[1], html.EscapeString(t))
 		} else {
-			fmt.Fprintf(w, "%s<br />\n", t)
+			fmt.Fprintf(&buf, "%s<br />\n", t)
 		}
 	}
 
-	fmt.Fprint(w, templatedown)
-
-	err = cmd.Wait()
-	if err != nil {
-		http.Error(w, "404 page not found (ag not found)", 404)
-		return
+	// 見つからないときも ag は 0 以外で終わる
+	if err := cmd.Wait(); err != nil {
+		log.Println(err)
 	}
 
+	renderPage(w, pg, buf.Bytes())
 	return
 }
 
@@ -443,7 +1089,19 @@ func main() {
 	flag.Parse()
 	cwd, _ := os.Getwd()
 
-	lrs := livereload.New("mkup")
+	if err := LoadConfig(*configFile); err != nil {
+		log.Fatal(err)
+	}
+	if _, _, err := MarkdownFlags(nil); err != nil {
+		log.Fatal(err)
+	}
+	RegisterExternalFormats()
+	themeCSS, err := ThemeCSS(*theme)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	lrs := newLiveReload("mkup")
 	defer lrs.Close()
 
 	go func() {
@@ -483,6 +1141,32 @@ func main() {
 		for {
 			select {
 			case event := <-fsw.Events:
+				if event.Op&(fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0 {
+					files.Invalidate()
+				}
+				// 新しいディレクトリも監視する (中身が先にできていることもある)
+				if event.Op&fsnotify.Create != 0 {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						filepath.Walk(event.Name, func(path string, info os.FileInfo, err error) error {
+							if info != nil && info.IsDir() {
+								fsw.Add(path)
+							}
+							return nil
+						})
+					}
+				}
+				if cf, _ := filepath.Abs(*configFile); cf == event.Name {
+					if err := LoadConfig(*configFile); err != nil {
+						log.Println(err)
+					}
+				}
+				// tail 表示のログはページを読み直さず行を送る
+				if IsTailed(event.Name) {
+					if event.Op&fsnotify.Write != 0 {
+						TailNotify(event.Name)
+					}
+					continue
+				}
 				if path, err := filepathRel(cwd, event.Name); err == nil {
 					path = "/" + filepath.ToSlash(path)
 					log.Println("reload", path)
@@ -509,14 +1193,27 @@ func main() {
 		return
 	})
 
+	http.HandleFunc("/_theme.css", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/css; charset=utf-8")
+		fmt.Fprint(w, themeCSS)
+		return
+	})
+
+	http.HandleFunc("/_task/", func(w http.ResponseWriter, r *http.Request) {
+		tasktoggle(cwd, w, r)
+		return
+	})
+
+	http.HandleFunc("/_tail/", func(w http.ResponseWriter, r *http.Request) {
+		tailstream(cwd, w, r)
+		return
+	})
+
 	http.HandleFunc("/_search/", func(w http.ResponseWriter, r *http.Request) {
 		search(cwd, w, r)
 		return
 	})
 
-	mdext := map[string]bool{".md": true, ".mkd": true, ".markdown": true}
-	imgext := map[string]bool{".jpeg": true, ".jpg": true, ".gif": true, ".png": true}
-
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		name := r.URL.Path
 
@@ -537,12 +1234,24 @@ func main() {
 			dirview(cwd, w, r)
 			return
 		} else {
-			ext := strings.ToLower(filepath.Ext(name))
-			if imgext[ext] {
-				imageview(cwd, w, r)
+			if MediaKind(name) != "" && r.FormValue("view") == "media" {
+				mediaview(cwd, w, r)
+				return
+			} else if MediaKind(name) != "" && r.FormValue("view") != "source" {
+				serveMedia(cwd, w, r)
+				return
+			} else if r.FormValue("view") == "source" {
+				fileview(cwd, w, r)
+				return
+			} else if PagedSource(name, info.Size()) {
+				// 全部読むと重いのでページ単位のソース表示
+				fileview(cwd, w, r)
+				return
+			} else if f := FormatFor(name); f != nil {
+				docview(cwd, f, w, r)
 				return
-			} else if mdext[ext] {
-				mdview(cwd, w, r)
+			} else if view := ViewFor(name); view != nil {
+				view(cwd, w, r)
 				return
 			} else {
 				fileview(cwd, w, r)
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// mathSpan latex formula cut out of markdown
+type mathSpan struct {
+	Tex     string
+	Display bool
+}
+
+var mathPlaceholderRe = regexp.MustCompile(`(<p>)?MKUPMATH(\d+)X(</p>)?`)
+
+func mathPlaceholder(i int) string {
+	return fmt.Sprintf("MKUPMATH%dX", i)
+}
+
+// ProtectMath replace $...$ / $$...$$ outside code with placeholders
+func ProtectMath(b []byte) ([]byte, []mathSpan) {
+	spans := []mathSpan{}
+	b = mapChunks(b, func(s string) string { return scanMath(s, &spans) })
+	return b, spans
+}
+
+func scanMath(s string, spans *[]mathSpan) string {
+	var out strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			if s[i+1] == '$' {
+				// blackfriday は \$ をエスケープとして扱わない
+				out.WriteByte('$')
+			} else {
+				out.WriteString(s[i : i+2])
+			}
+			i += 2
+			continue
+		case c == '`':
+			n := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+			run := s[i : i+n]
+			if j := strings.Index(s[i+n:], run); j >= 0 {
+				out.WriteString(s[i : i+n+j+n])
+				i += n + j + n
+			} else {
+				out.WriteString(run)
+				i += n
+			}
+			continue
+		case strings.HasPrefix(s[i:], "$$"):
+			if j := strings.Index(s[i+2:], "$$"); j >= 0 && strings.TrimSpace(s[i+2:i+2+j]) != "" {
+				out.WriteString(mathPlaceholder(len(*spans)))
+				*spans = append(*spans, mathSpan{strings.TrimSpace(s[i+2 : i+2+j]), true})
+				i += j + 4
+				continue
+			}
+			out.WriteString("$$")
+			i += 2
+			continue
+		case c == '$':
+			if j := inlineMathEnd(s, i); j > 0 {
+				out.WriteString(mathPlaceholder(len(*spans)))
+				*spans = append(*spans, mathSpan{s[i+1 : j], false})
+				i = j + 1
+				continue
+			}
+		}
+		out.WriteByte(c)
+		i++
+	}
+	return out.String()
+}
+
+// inlineMathEnd closing $ index, pandoc rules (no space inside, no digit after)
+func inlineMathEnd(s string, i int) int {
+	if i+1 >= len(s) || strings.ContainsRune(" \t\n$", rune(s[i+1])) {
+		return -1
+	}
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '\n', '`':
+			return -1
+		case '$':
+			if strings.ContainsRune(" \t", rune(s[j-1])) {
+				return -1
+			}
+			if j+1 < len(s) && s[j+1] >= '0' && s[j+1] <= '9' {
+				return -1
+			}
+			return j
+		}
+	}
+	return -1
+}
+
+// RestoreMath placeholders to math elements for katex
+func RestoreMath(b []byte, spans []mathSpan) []byte {
+	if len(spans) == 0 {
+		return b
+	}
+	return mathPlaceholderRe.ReplaceAllFunc(b, func(m []byte) []byte {
+		sm := mathPlaceholderRe.FindSubmatch(m)
+		var n int
+		fmt.Sscan(string(sm[2]), &n)
+		if n >= len(spans) {
+			return m
+		}
+		sp := spans[n]
+		tex := html.EscapeString(sp.Tex)
+		if sp.Display && len(sm[1]) > 0 && len(sm[3]) > 0 {
+			return []byte(`<div class="math display">` + tex + `</div>`)
+		}
+		cls := "inline"
+		if sp.Display {
+			cls = "display"
+		}
+		return []byte(string(sm[1]) + `<span class="math ` + cls + `">` + tex + `</span>` + string(sm[3]))
+	})
+}
+
+// RestoreMathText placeholders to raw tex (toc etc.)
+func RestoreMathText(s string, spans []mathSpan) string {
+	if len(spans) == 0 {
+		return s
+	}
+	return string(mathPlaceholderRe.ReplaceAllFunc([]byte(s), func(m []byte) []byte {
+		sm := mathPlaceholderRe.FindSubmatch(m)
+		var n int
+		fmt.Sscan(string(sm[2]), &n)
+		if n >= len(spans) {
+			return m
+		}
+		return []byte(string(sm[1]) + spans[n].Tex + string(sm[3]))
+	}))
+}
+
+// HasAsset embedded asset exists
+func HasAsset(name string) bool {
+	_, err := AssetInfo(name)
+	return err == nil
+}
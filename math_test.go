@@ -0,0 +1,71 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProtectMath(t *testing.T) {
+	tests := []struct {
+		src   string
+		out   string
+		spans []mathSpan
+	}{
+		{"costs $5 and $10", "costs $5 and $10", nil},
+		{"$x^2$", "MKUPMATH0X", []mathSpan{{"x^2", false}}},
+		{"$a$ and $b$", "MKUPMATH0X and MKUPMATH1X", []mathSpan{{"a", false}, {"b", false}}},
+		// pandoc と同じく内側の空白, 直後の数字は math にしない
+		{"$ x $", "$ x $", nil},
+		{"$x $", "$x $", nil},
+		{"$x$5", "$x$5", nil},
+		{"$a\nb$", "$a\nb$", nil},
+		{`\$5 and $x$`, "$5 and MKUPMATH0X", []mathSpan{{"x", false}}},
+		{`$a\$b$`, "MKUPMATH0X", []mathSpan{{`a\$b`, false}}},
+		{"`$x$` $y$", "`$x$` MKUPMATH0X", []mathSpan{{"y", false}}},
+		{"``a ` $x$``", "``a ` $x$``", nil},
+		{"$$\nE = mc^2\n$$", "MKUPMATH0X", []mathSpan{{"E = mc^2", true}}},
+		{"$$ $$", "$$ $$", nil},
+		{"```\n$x$\n```\n", "```\n$x$\n```\n", nil},
+		{"text\n\n    $x$\n", "text\n\n    $x$\n", nil},
+	}
+	for _, tt := range tests {
+		out, spans := ProtectMath([]byte(tt.src))
+		if string(out) != tt.out {
+			t.Errorf("ProtectMath(%q) = %q, want %q", tt.src, out, tt.out)
+		}
+		if len(spans) == 0 {
+			spans = nil
+		}
+		if !reflect.DeepEqual(spans, tt.spans) {
+			t.Errorf("ProtectMath(%q) spans = %v, want %v", tt.src, spans, tt.spans)
+		}
+	}
+}
+
+func TestRenderMath(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"costs $5 and $10", "<p>costs $5 and $10</p>"},
+		{"a $x<y$ b", `<p>a <span class="math inline">x&lt;y</span> b</p>`},
+		{"$$\nx_1\n$$", `<div class="math display">x_1</div>`},
+		{"a $$x$$ b", `<p>a <span class="math display">x</span> b</p>`},
+		// _ や * を強調にしない
+		{"$a_1 * b_2$", `<span class="math inline">a_1 * b_2</span>`},
+	}
+	for _, tt := range tests {
+		pg := page{}
+		got := string(RenderMarkdown(t.TempDir(), "/a.md", []byte(tt.src), nil, &pg))
+		if !strings.Contains(got, tt.want) {
+			t.Errorf("%q:\n got %s\nwant %s", tt.src, got, tt.want)
+		}
+	}
+
+	pg := page{}
+	RenderMarkdown(t.TempDir(), "/a.md", []byte("# Energy $E$\n"), nil, &pg)
+	if len(pg.Toc) != 1 || pg.Toc[0].Text != "Energy E" || pg.Toc[0].ID != "energy-e" {
+		t.Errorf("toc = %+v, want the tex in the heading text", pg.Toc)
+	}
+}
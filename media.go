@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// mediaKinds viewer kind by extension
+var mediaKinds = map[string]string{
+	".png":  "image",
+	".jpg":  "image",
+	".jpeg": "image",
+	".gif":  "image",
+	".svg":  "image",
+	".webp": "image",
+	".avif": "image",
+	".mp4":  "video",
+	".m4v":  "video",
+	".webm": "video",
+	".ogv":  "video",
+	".mp3":  "audio",
+	".m4a":  "audio",
+	".ogg":  "audio",
+	".oga":  "audio",
+	".opus": "audio",
+	".wav":  "audio",
+	".flac": "audio",
+	".pdf":  "pdf",
+}
+
+// mediaTypes content types missing from some mime tables
+var mediaTypes = map[string]string{
+	".svg":  "image/svg+xml",
+	".webp": "image/webp",
+	".avif": "image/avif",
+	".mp4":  "video/mp4",
+	".m4v":  "video/mp4",
+	".webm": "video/webm",
+	".ogv":  "video/ogg",
+	".mp3":  "audio/mpeg",
+	".m4a":  "audio/mp4",
+	".ogg":  "audio/ogg",
+	".oga":  "audio/ogg",
+	".opus": "audio/ogg",
+	".wav":  "audio/wav",
+	".flac": "audio/flac",
+	".pdf":  "application/pdf",
+}
+
+func init() {
+	for ext, typ := range mediaTypes {
+		if mime.TypeByExtension(ext) == "" {
+			mime.AddExtensionType(ext, typ)
+		}
+	}
+}
+
+// MediaKind image, video, audio or pdf, "" when not media
+func MediaKind(name string) string {
+	return mediaKinds[strings.ToLower(filepath.Ext(name))]
+}
+
+// serveMedia file as is with its content type and range support,
+// ?download=1 as attachment
+func serveMedia(cwd string, w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Path
+	f, err := os.Open(filepath.Join(cwd, name))
+	if err != nil {
+		http.Error(w, "404 page not found", 404)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		http.Error(w, "404 page not found", 404)
+		return
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	if typ := mime.TypeByExtension(ext); typ != "" {
+		w.Header().Set("Content-Type", typ)
+	}
+	// 直接開いた svg のスクリプトは動かさない
+	if ext == ".svg" {
+		w.Header().Set("Content-Security-Policy", "script-src 'none'")
+	}
+	if r.FormValue("download") != "" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(filepath.Base(name))))
+	}
+	http.ServeContent(w, r, filepath.Base(name), info.ModTime(), f)
+	return
+}
+
+// mediaview viewer page of an image, video, audio or pdf file (?view=media)
+func mediaview(cwd string, w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Path
+	info, err := os.Stat(filepath.Join(cwd, name))
+	if err != nil {
+		http.Error(w, "404 page not found", 404)
+		return
+	}
+
+	pg := page{}
+	pg.Title = filepath.Base(name) + " - mkup"
+
+	// 階層メニュー Dirnests
+	rd := filepath.Dir(name)
+	MenuDir(rd, &pg)
+
+	var buf bytes.Buffer
+	src := html.EscapeString((&url.URL{Path: name}).String())
+	base := html.EscapeString(filepath.Base(name))
+	fmt.Fprintf(&buf, "<h1>%s</h1>\n<div class=\"media-view\">\n", base)
+	switch MediaKind(name) {
+	case "image":
+		fmt.Fprintf(&buf, "<a href=\"%s\"><img src=\"%s\" alt=\"%s\"></a>\n", src, src, base)
+	case "video":
+		fmt.Fprintf(&buf, "<video src=\"%s\" controls preload=\"metadata\"></video>\n", src)
+	case "audio":
+		fmt.Fprintf(&buf, "<audio src=\"%s\" controls preload=\"metadata\"></audio>\n", src)
+	case "pdf":
+		fmt.Fprintf(&buf, "<object class=\"media-pdf\" data=\"%s\" type=\"application/pdf\"><p><a href=\"%s\">%s</a></p></object>\n", src, src, base)
+	}
+	fmt.Fprintf(&buf, "</div>\n<p class=\"media-info\">%s, %s &middot; <a href=\"%s\">open</a> &middot; <a href=\"%s?download=1\">download</a></p>\n",
+		html.EscapeString(mime.TypeByExtension(strings.ToLower(filepath.Ext(name)))), ByteSize(info.Size()), src, src)
+
+	renderPage(w, pg, buf.Bytes())
+	return
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// notebookMaxSize larger notebooks are shown as source, outputs with images
+// easily go over largeFileSize
+const notebookMaxSize = 64 << 20
+
+// nbText notebook multiline string (string or list of lines, other json kept as is)
+type nbText string
+
+func (t *nbText) UnmarshalJSON(b []byte) error {
+	var s string
+	var l []string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*t = nbText(s)
+	} else if err := json.Unmarshal(b, &l); err == nil {
+		*t = nbText(strings.Join(l, ""))
+	} else {
+		*t = nbText(b)
+	}
+	return nil
+}
+
+// notebook jupyter nbformat 4
+type notebook struct {
+	Cells []struct {
+		CellType       string `json:"cell_type"`
+		Source         nbText `json:"source"`
+		ExecutionCount *int   `json:"execution_count"`
+		Outputs        []struct {
+			OutputType string            `json:"output_type"`
+			Name       string            `json:"name"`
+			Text       nbText            `json:"text"`
+			Data       map[string]nbText `json:"data"`
+			Ename      string            `json:"ename"`
+			Evalue     string            `json:"evalue"`
+			Traceback  []string          `json:"traceback"`
+		} `json:"outputs"`
+	} `json:"cells"`
+	Metadata struct {
+		Kernelspec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+}
+
+// notebookDoc jupyter notebook document
+func notebookDoc(cwd, name string, b []byte, pg *page) ([]byte, error) {
+	var nb notebook
+	if err := json.Unmarshal(b, &nb); err != nil {
+		return nil, err
+	}
+	lang := nb.Metadata.LanguageInfo.Name
+	if lang == "" {
+		lang = nb.Metadata.Kernelspec.Language
+	}
+	if lang == "" {
+		lang = "python"
+	}
+
+	var buf bytes.Buffer
+	for _, c := range nb.Cells {
+		src := string(c.Source)
+		switch c.CellType {
+		case "markdown":
+			buf.WriteString("<div class=\"nb-cell nb-markdown\">\n")
+			buf.Write(RenderMarkdown(cwd, name, []byte(src), nil, pg))
+			buf.WriteString("</div>\n")
+		case "code":
+			prompt := " "
+			if c.ExecutionCount != nil {
+				prompt = fmt.Sprint(*c.ExecutionCount)
+			}
+			fmt.Fprintf(&buf, "<div class=\"nb-cell nb-code\">\n<div class=\"nb-prompt\">In [%s]:</div>\n", prompt)
+			buf.WriteString(Highlight(src, lang, "", false))
+			for _, o := range c.Outputs {
+				buf.WriteString("<div class=\"nb-output\">\n")
+				switch o.OutputType {
+				case "stream":
+					fmt.Fprintf(&buf, "<pre class=\"nb-stream nb-%s\">%s</pre>\n", html.EscapeString(o.Name), html.EscapeString(string(o.Text)))
+				case "error":
+					tb := StripANSI(strings.Join(o.Traceback, "\n"))
+					if tb == "" {
+						tb = o.Ename + ": " + o.Evalue
+					}
+					fmt.Fprintf(&buf, "<pre class=\"nb-error\">%s</pre>\n", html.EscapeString(tb))
+				default:
+					buf.WriteString(nbData(cwd, name, o.Data, pg))
+				}
+				buf.WriteString("</div>\n")
+			}
+			buf.WriteString("</div>\n")
+		default:
+			fmt.Fprintf(&buf, "<div class=\"nb-cell nb-raw\"><pre>%s</pre></div>\n", html.EscapeString(src))
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// nbData richest displayable representation of a display_data / execute_result
+func nbData(cwd, name string, data map[string]nbText, pg *page) string {
+	for _, mt := range []string{"image/png", "image/jpeg", "image/gif"} {
+		if d, ok := data[mt]; ok {
+			return fmt.Sprintf("<img src=\"data:%s;base64,%s\">\n", mt, strings.Replace(string(d), "\n", "", -1))
+		}
+	}
+	if d, ok := data["image/svg+xml"]; ok {
+		return fmt.Sprintf("<img src=\"data:image/svg+xml;base64,%s\">\n", base64.StdEncoding.EncodeToString([]byte(d)))
+	}
+	if d, ok := data["text/html"]; ok {
+		return "<div class=\"nb-html\">" + string(d) + "</div>\n"
+	}
+	if d, ok := data["text/markdown"]; ok {
+		return string(RenderMarkdown(cwd, name, []byte(d), nil, pg))
+	}
+	if d, ok := data["text/latex"]; ok {
+		pg.Math = pg.Math || HasAsset("_assets/katex/katex.min.js")
+		return "<div class=\"math display\">" + html.EscapeString(strings.Trim(strings.TrimSpace(string(d)), "$")) + "</div>\n"
+	}
+	if d, ok := data["text/plain"]; ok {
+		return "<pre>" + html.EscapeString(string(d)) + "</pre>\n"
+	}
+	return ""
+}
@@ -0,0 +1,739 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// apiMethods operation keys of a path item, display order
+var apiMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// apiSpec openapi 3 / swagger 2 document and the local files its $refs point to
+type apiSpec struct {
+	cwd   string
+	file  string
+	root  interface{}
+	files map[string]interface{}
+	pg    *page
+	name  string
+}
+
+// apiVal value and the file its relative $refs are resolved against
+type apiVal struct {
+	V    interface{}
+	File string
+}
+
+// ParseOpenAPI spec of file name, nil when b is not an openapi / swagger document
+func ParseOpenAPI(cwd, name string, b []byte) *apiSpec {
+	root, err := UnmarshalOrdered(b)
+	if err != nil {
+		return nil
+	}
+	if apiStr(root, "openapi") == "" && apiStr(root, "swagger") == "" {
+		return nil
+	}
+	fp := filepath.Join(cwd, name)
+	return &apiSpec{cwd: cwd, file: fp, root: root, files: map[string]interface{}{fp: root}, name: name}
+}
+
+// IsSwagger swagger 2.0 (definitions, body parameters ...)
+func (s *apiSpec) IsSwagger() bool {
+	return apiStr(s.root, "swagger") != ""
+}
+
+// apiGet value of key in a mapping
+func apiGet(v interface{}, key string) interface{} {
+	switch m := v.(type) {
+	case yaml.MapSlice:
+		for _, it := range m {
+			if fmt.Sprint(it.Key) == key {
+				return it.Value
+			}
+		}
+	case map[string]interface{}:
+		return m[key]
+	}
+	return nil
+}
+
+// apiStr string value of key
+func apiStr(v interface{}, key string) string {
+	switch s := apiGet(v, key).(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	default:
+		return fmt.Sprint(s)
+	}
+}
+
+// apiList sequence value of key
+func apiList(v interface{}, key string) []interface{} {
+	l, _ := apiGet(v, key).([]interface{})
+	return l
+}
+
+// apiItems mapping as ordered items
+func apiItems(v interface{}) yaml.MapSlice {
+	switch m := v.(type) {
+	case yaml.MapSlice:
+		return m
+	case map[string]interface{}:
+		ks := []string{}
+		for k := range m {
+			ks = append(ks, k)
+		}
+		sort.Strings(ks)
+		items := yaml.MapSlice{}
+		for _, k := range ks {
+			items = append(items, yaml.MapItem{Key: k, Value: m[k]})
+		}
+		return items
+	}
+	return nil
+}
+
+// load parsed local file, only inside the served root
+func (s *apiSpec) load(fp string) (interface{}, error) {
+	if v, ok := s.files[fp]; ok {
+		return v, nil
+	}
+	if !insideRoot(s.cwd, fp) {
+		return nil, fmt.Errorf("outside of the served root")
+	}
+	b, err := ioutil.ReadFile(fp)
+	if err != nil {
+		return nil, err
+	}
+	v, err := UnmarshalOrdered(b)
+	if err != nil {
+		return nil, err
+	}
+	s.files[fp] = v
+	return v, nil
+}
+
+// Deref follow $ref (same document or local file), name is the referenced component
+func (s *apiSpec) Deref(a apiVal) (apiVal, string, error) {
+	name := ""
+	for hop := 0; hop < 32; hop++ {
+		ref, ok := apiGet(a.V, "$ref").(string)
+		if !ok {
+			return a, name, nil
+		}
+		file, ptr := ref, ""
+		if i := strings.Index(ref, "#"); i >= 0 {
+			file, ptr = ref[:i], ref[i+1:]
+		}
+		if Match(`^[a-zA-Z][a-zA-Z0-9+.-]*:`, file) {
+			return a, name, fmt.Errorf("%s: remote $ref is not resolved", ref)
+		}
+		fp := a.File
+		if file != "" {
+			if p, err := url.PathUnescape(file); err == nil {
+				file = p
+			}
+			fp = filepath.Join(filepath.Dir(a.File), filepath.FromSlash(file))
+		}
+		doc, err := s.load(fp)
+		if err != nil {
+			return a, name, fmt.Errorf("%s: %v", ref, err)
+		}
+		v, err := jsonPointer(doc, ptr)
+		if err != nil {
+			return a, name, fmt.Errorf("%s: %v", ref, err)
+		}
+		if ptr != "" {
+			name = ptr[strings.LastIndex(ptr, "/")+1:]
+		} else {
+			name = strings.TrimSuffix(filepath.Base(fp), filepath.Ext(fp))
+		}
+		a = apiVal{v, fp}
+	}
+	return a, name, fmt.Errorf("too many $ref hops")
+}
+
+// jsonPointer value at rfc 6901 pointer ("/components/schemas/Pet")
+func jsonPointer(doc interface{}, ptr string) (interface{}, error) {
+	if p, err := url.PathUnescape(ptr); err == nil {
+		ptr = p
+	}
+	v := doc
+	for _, tok := range strings.Split(ptr, "/")[1:] {
+		tok = strings.Replace(strings.Replace(tok, "~1", "/", -1), "~0", "~", -1)
+		switch c := v.(type) {
+		case []interface{}:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, fmt.Errorf("no index %q", tok)
+			}
+			v = c[i]
+		default:
+			n := apiGet(c, tok)
+			if n == nil {
+				return nil, fmt.Errorf("%q not found", tok)
+			}
+			v = n
+		}
+	}
+	return v, nil
+}
+
+// apiOp operation of a path
+type apiOp struct {
+	Method, Path string
+	Op, Item     apiVal
+}
+
+// HTML api reference page, headings go to pg.Toc
+func (s *apiSpec) HTML(pg *page) []byte {
+	s.pg = pg
+	var buf bytes.Buffer
+	root := s.root
+	info := apiGet(root, "info")
+
+	title := apiStr(info, "title")
+	if title == "" {
+		title = filepath.Base(s.name)
+	}
+	pg.Title = title + " - mkup"
+	version := apiStr(root, "openapi")
+	if s.IsSwagger() {
+		version = "Swagger " + apiStr(root, "swagger")
+	} else {
+		version = "OpenAPI " + version
+	}
+	fmt.Fprintf(&buf, "<div class=\"api\">\n<h1>%s <span class=\"api-version\">%s</span> <span class=\"api-version\">%s</span></h1>\n",
+		html.EscapeString(title), html.EscapeString(apiStr(info, "version")), html.EscapeString(version))
+	buf.WriteString(s.markdown(apiStr(info, "description")))
+
+	// servers
+	servers := []string{}
+	for _, sv := range apiList(root, "servers") {
+		servers = append(servers, apiStr(sv, "url"))
+	}
+	if host := apiStr(root, "host"); host != "" {
+		schemes := []string{}
+		for _, sc := range apiList(root, "schemes") {
+			schemes = append(schemes, fmt.Sprint(sc))
+		}
+		if len(schemes) == 0 {
+			schemes = []string{"https"}
+		}
+		for _, sc := range schemes {
+			servers = append(servers, sc+"://"+host+apiStr(root, "basePath"))
+		}
+	}
+	if len(servers) > 0 {
+		buf.WriteString("<p class=\"api-servers\">Servers:")
+		for _, sv := range servers {
+			fmt.Fprintf(&buf, " <code>%s</code>", html.EscapeString(sv))
+		}
+		buf.WriteString("</p>\n")
+	}
+
+	// tag ごとにまとめる (tags の順, 無ければ出現順)
+	tagDesc := map[string]string{}
+	tags := []string{}
+	for _, t := range apiList(root, "tags") {
+		name := apiStr(t, "name")
+		tagDesc[name] = apiStr(t, "description")
+		tags = append(tags, name)
+	}
+	groups := map[string][]apiOp{}
+	for _, p := range apiItems(apiGet(root, "paths")) {
+		path := fmt.Sprint(p.Key)
+		item, _, err := s.Deref(apiVal{p.Value, s.file})
+		if err != nil {
+			buf.WriteString(extRenderError(path, err))
+			continue
+		}
+		for _, m := range apiMethods {
+			op := apiGet(item.V, m)
+			if op == nil {
+				continue
+			}
+			tag := "default"
+			if l := apiList(op, "tags"); len(l) > 0 {
+				tag = fmt.Sprint(l[0])
+			}
+			if _, ok := groups[tag]; !ok {
+				if _, listed := tagDesc[tag]; !listed {
+					tags = append(tags, tag)
+					tagDesc[tag] = ""
+				}
+			}
+			groups[tag] = append(groups[tag], apiOp{m, path, apiVal{op, item.File}, item})
+		}
+	}
+	for _, tag := range tags {
+		if len(groups[tag]) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, "<h2>%s</h2>\n", html.EscapeString(tag))
+		buf.WriteString(s.markdown(tagDesc[tag]))
+		for _, op := range groups[tag] {
+			s.operation(&buf, op)
+		}
+	}
+
+	// schemas
+	schemas := apiItems(apiGet(apiGet(root, "components"), "schemas"))
+	if s.IsSwagger() {
+		schemas = apiItems(apiGet(root, "definitions"))
+	}
+	if len(schemas) > 0 {
+		buf.WriteString("<h2>Schemas</h2>\n")
+		for _, sc := range schemas {
+			fmt.Fprintf(&buf, "<h3>%s</h3>\n", html.EscapeString(fmt.Sprint(sc.Key)))
+			sv := apiVal{sc.Value, s.file}
+			if _, name, _ := s.Deref(sv); name != "" {
+				buf.WriteString("<p>" + s.typeName(sv) + "</p>\n")
+			}
+			buf.WriteString(s.schema(sv, 0, map[string]bool{fmt.Sprint(sc.Key): true}))
+		}
+	}
+	buf.WriteString("</div>\n")
+	return HeadingToc(buf.Bytes(), pg)
+}
+
+// operation one method of a path
+func (s *apiSpec) operation(buf *bytes.Buffer, o apiOp) {
+	op := o.Op.V
+	cls := "api-op api-" + o.Method
+	if apiGet(op, "deprecated") == true {
+		cls += " api-deprecated"
+	}
+	fmt.Fprintf(buf, "<div class=\"%s\">\n<h3><span class=\"api-method\">%s</span> <code>%s</code> %s</h3>\n",
+		cls, strings.ToUpper(o.Method), html.EscapeString(o.Path), html.EscapeString(apiStr(op, "summary")))
+	if id := apiStr(op, "operationId"); id != "" {
+		fmt.Fprintf(buf, "<p class=\"api-opid\">operationId: <code>%s</code></p>\n", html.EscapeString(id))
+	}
+	buf.WriteString(s.markdown(apiStr(op, "description")))
+
+	// path 共通と operation の parameters (name+in で上書き)
+	params := []apiVal{}
+	index := map[string]int{}
+	for _, src := range []apiVal{o.Item, o.Op} {
+		for _, p := range apiList(src.V, "parameters") {
+			pv, _, err := s.Deref(apiVal{p, src.File})
+			if err != nil {
+				buf.WriteString(extRenderError("parameter", err))
+				continue
+			}
+			key := apiStr(pv.V, "in") + ":" + apiStr(pv.V, "name")
+			if i, ok := index[key]; ok {
+				params[i] = pv
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, pv)
+		}
+	}
+
+	rows := []apiVal{}
+	var body *apiVal
+	for i, p := range params {
+		// swagger 2 の body は request body として
+		if apiStr(p.V, "in") == "body" {
+			body = &params[i]
+			continue
+		}
+		rows = append(rows, p)
+	}
+	if len(rows) > 0 {
+		buf.WriteString("<p class=\"api-section\">Parameters</p>\n<table class=\"api-params\">\n<thead>\n<tr><th>Name</th><th>In</th><th>Type</th><th>Description</th></tr>\n</thead>\n<tbody>\n")
+		for _, p := range rows {
+			req := ""
+			if apiGet(p.V, "required") == true {
+				req = " <span class=\"api-required\">required</span>"
+			}
+			sc := apiVal{apiGet(p.V, "schema"), p.File}
+			if sc.V == nil {
+				sc = p
+			}
+			fmt.Fprintf(buf, "<tr><td><code>%s</code>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+				html.EscapeString(apiStr(p.V, "name")), req, html.EscapeString(apiStr(p.V, "in")),
+				s.typeName(sc), s.markdown(apiStr(p.V, "description")))
+		}
+		buf.WriteString("</tbody>\n</table>\n")
+	}
+
+	if body != nil {
+		buf.WriteString("<p class=\"api-section\">Request body</p>\n")
+		buf.WriteString(s.markdown(apiStr(body.V, "description")))
+		s.media(buf, "", apiVal{apiGet(body.V, "schema"), body.File}, nil)
+	}
+	if rb := apiGet(op, "requestBody"); rb != nil {
+		rv, _, err := s.Deref(apiVal{rb, o.Op.File})
+		if err != nil {
+			buf.WriteString(extRenderError("requestBody", err))
+		} else {
+			req := ""
+			if apiGet(rv.V, "required") == true {
+				req = " <span class=\"api-required\">required</span>"
+			}
+			fmt.Fprintf(buf, "<p class=\"api-section\">Request body%s</p>\n", req)
+			buf.WriteString(s.markdown(apiStr(rv.V, "description")))
+			s.content(buf, apiVal{apiGet(rv.V, "content"), rv.File})
+		}
+	}
+
+	if resps := apiItems(apiGet(op, "responses")); len(resps) > 0 {
+		buf.WriteString("<p class=\"api-section\">Responses</p>\n")
+		for _, it := range resps {
+			code := fmt.Sprint(it.Key)
+			rv, _, err := s.Deref(apiVal{it.Value, o.Op.File})
+			if err != nil {
+				buf.WriteString(extRenderError(code, err))
+				continue
+			}
+			class := ""
+			if len(code) > 0 {
+				class = code[:1]
+			}
+			fmt.Fprintf(buf, "<div class=\"api-response\">\n<p><span class=\"api-status api-status-%s\">%s</span> %s</p>\n",
+				html.EscapeString(class), html.EscapeString(code), html.EscapeString(apiStr(rv.V, "description")))
+			if s.IsSwagger() {
+				if sc := apiGet(rv.V, "schema"); sc != nil {
+					s.media(buf, "", apiVal{sc, rv.File}, apiItems(apiGet(rv.V, "examples")))
+				}
+			} else {
+				s.content(buf, apiVal{apiGet(rv.V, "content"), rv.File})
+			}
+			buf.WriteString("</div>\n")
+		}
+	}
+	buf.WriteString("</div>\n")
+}
+
+// content openapi 3 media type map
+func (s *apiSpec) content(buf *bytes.Buffer, c apiVal) {
+	for _, it := range apiItems(c.V) {
+		mt := apiVal{it.Value, c.File}
+		examples := yaml.MapSlice{}
+		if ex := apiGet(mt.V, "example"); ex != nil {
+			examples = append(examples, yaml.MapItem{Key: "example", Value: ex})
+		}
+		for _, ex := range apiItems(apiGet(mt.V, "examples")) {
+			ev, _, err := s.Deref(apiVal{ex.Value, mt.File})
+			if err != nil {
+				continue
+			}
+			examples = append(examples, yaml.MapItem{Key: ex.Key, Value: apiGet(ev.V, "value")})
+		}
+		s.media(buf, fmt.Sprint(it.Key), apiVal{apiGet(mt.V, "schema"), mt.File}, examples)
+	}
+}
+
+// media schema and examples of one media type (examples generated from the schema when none)
+func (s *apiSpec) media(buf *bytes.Buffer, mediaType string, schema apiVal, examples yaml.MapSlice) {
+	if mediaType != "" {
+		fmt.Fprintf(buf, "<p class=\"api-media\"><code>%s</code></p>\n", html.EscapeString(mediaType))
+	}
+	buf.WriteString("<div class=\"api-body\">\n")
+	if _, _, err := s.Deref(schema); err != nil {
+		buf.WriteString("<p class=\"api-error\">" + html.EscapeString(err.Error()) + "</p>\n</div>\n")
+		return
+	}
+	if schema.V != nil {
+		buf.WriteString("<div class=\"api-body-schema\">\n<p>" + s.typeName(schema) + "</p>\n")
+		buf.WriteString(s.schema(schema, 0, map[string]bool{}))
+		buf.WriteString("</div>\n")
+	}
+	if len(examples) == 0 && schema.V != nil {
+		if ex := s.sample(schema, 0, map[string]bool{}); ex != nil {
+			examples = yaml.MapSlice{{Key: "example", Value: ex}}
+		}
+	}
+	for _, ex := range examples {
+		buf.WriteString("<div class=\"api-example\">\n")
+		if len(examples) > 1 {
+			fmt.Fprintf(buf, "<p class=\"api-media\">%s</p>\n", html.EscapeString(fmt.Sprint(ex.Key)))
+		}
+		if str, ok := ex.Value.(string); ok {
+			buf.WriteString(Highlight(str, "", "", false))
+		} else {
+			buf.WriteString(Highlight(apiJSON(ex.Value, ""), "json", "", false))
+		}
+		buf.WriteString("</div>\n")
+	}
+	buf.WriteString("</div>\n")
+}
+
+// typeName short type of a schema (Pet, array of string, string (date-time) ...)
+func (s *apiSpec) typeName(a apiVal) string {
+	v, name, err := s.Deref(a)
+	if err != nil {
+		return "<span class=\"api-error\">" + html.EscapeString(err.Error()) + "</span>"
+	}
+	if name != "" {
+		return "<span class=\"api-type\">" + html.EscapeString(name) + "</span>"
+	}
+	t := apiStr(v.V, "type")
+	if t == "" {
+		switch {
+		case apiGet(v.V, "properties") != nil:
+			t = "object"
+		case apiGet(v.V, "oneOf") != nil:
+			t = "oneOf"
+		case apiGet(v.V, "anyOf") != nil:
+			t = "anyOf"
+		case apiGet(v.V, "allOf") != nil:
+			t = "allOf"
+		default:
+			t = "any"
+		}
+	}
+	if t == "array" {
+		return "<span class=\"api-type\">array</span> of " + s.typeName(apiVal{apiGet(v.V, "items"), v.File})
+	}
+	if f := apiStr(v.V, "format"); f != "" {
+		t += " (" + f + ")"
+	}
+	out := "<span class=\"api-type\">" + html.EscapeString(t) + "</span>"
+	if enum := apiList(v.V, "enum"); len(enum) > 0 {
+		vals := []string{}
+		for _, e := range enum {
+			vals = append(vals, "<code>"+html.EscapeString(fmt.Sprint(e))+"</code>")
+		}
+		out += " enum: " + strings.Join(vals, ", ")
+	}
+	if d := apiGet(v.V, "default"); d != nil {
+		out += " default: <code>" + html.EscapeString(fmt.Sprint(d)) + "</code>"
+	}
+	return out
+}
+
+// schema properties as nested list, seen stops recursive schemas
+func (s *apiSpec) schema(a apiVal, depth int, seen map[string]bool) string {
+	v, name, err := s.Deref(a)
+	if err != nil {
+		return "<span class=\"api-error\">" + html.EscapeString(err.Error()) + "</span>\n"
+	}
+	if name != "" {
+		if seen[name] || depth > 8 {
+			return "<p class=\"api-schema\">(recursive)</p>\n"
+		}
+		seen = copySeen(seen, name)
+	}
+
+	var buf strings.Builder
+	buf.WriteString("<div class=\"api-schema\">\n")
+	if d := apiStr(v.V, "description"); d != "" && depth == 0 {
+		buf.WriteString(s.markdown(d))
+	}
+
+	for _, k := range []string{"oneOf", "anyOf"} {
+		if alts := apiList(v.V, k); len(alts) > 0 {
+			fmt.Fprintf(&buf, "<p>%s:</p>\n<ul>\n", k)
+			for _, alt := range alts {
+				av := apiVal{alt, v.File}
+				buf.WriteString("<li>" + s.typeName(av))
+				if s.nested(av) {
+					buf.WriteString(s.schema(av, depth+1, seen))
+				}
+				buf.WriteString("</li>\n")
+			}
+			buf.WriteString("</ul>\n")
+		}
+	}
+
+	props, required := s.properties(v, seen)
+	if len(props) > 0 {
+		buf.WriteString("<ul class=\"api-props\">\n")
+		for _, p := range props {
+			pv := p.Value.(apiVal)
+			req := ""
+			if required[fmt.Sprint(p.Key)] {
+				req = " <span class=\"api-required\">required</span>"
+			}
+			fmt.Fprintf(&buf, "<li><code>%s</code> %s%s %s", html.EscapeString(fmt.Sprint(p.Key)), s.typeName(pv), req,
+				html.EscapeString(apiStr(s.deref(pv).V, "description")))
+			if s.nested(pv) {
+				buf.WriteString(s.schema(pv, depth+1, seen))
+			}
+			buf.WriteString("</li>\n")
+		}
+		buf.WriteString("</ul>\n")
+	}
+
+	if t := apiStr(v.V, "type"); t == "array" {
+		items := apiVal{apiGet(v.V, "items"), v.File}
+		if s.nested(items) {
+			buf.WriteString(s.schema(items, depth+1, seen))
+		}
+	}
+	if ap := apiGet(v.V, "additionalProperties"); ap != nil && ap != true && ap != false {
+		av := apiVal{ap, v.File}
+		buf.WriteString("<p>additional properties: " + s.typeName(av) + "</p>\n")
+		if s.nested(av) {
+			buf.WriteString(s.schema(av, depth+1, seen))
+		}
+	}
+	buf.WriteString("</div>\n")
+	return buf.String()
+}
+
+// properties own and allOf properties (values are apiVal), required names
+func (s *apiSpec) properties(v apiVal, seen map[string]bool) (yaml.MapSlice, map[string]bool) {
+	props := yaml.MapSlice{}
+	required := map[string]bool{}
+	for _, sub := range apiList(v.V, "allOf") {
+		sv, name, err := s.Deref(apiVal{sub, v.File})
+		if err != nil || seen[name] && name != "" {
+			continue
+		}
+		p, r := s.properties(sv, copySeen(seen, name))
+		props = append(props, p...)
+		for k := range r {
+			required[k] = true
+		}
+	}
+	for _, r := range apiList(v.V, "required") {
+		required[fmt.Sprint(r)] = true
+	}
+	for _, it := range apiItems(apiGet(v.V, "properties")) {
+		props = append(props, yaml.MapItem{Key: it.Key, Value: apiVal{it.Value, v.File}})
+	}
+	return props, required
+}
+
+// nested schema worth expanding below a property (objects, arrays of objects, alternatives)
+func (s *apiSpec) nested(a apiVal) bool {
+	v := s.deref(a)
+	switch {
+	case apiGet(v.V, "properties") != nil, apiGet(v.V, "allOf") != nil,
+		apiGet(v.V, "oneOf") != nil, apiGet(v.V, "anyOf") != nil:
+		return true
+	case apiStr(v.V, "type") == "array":
+		return s.nested(apiVal{apiGet(v.V, "items"), v.File})
+	}
+	return false
+}
+
+func (s *apiSpec) deref(a apiVal) apiVal {
+	v, _, _ := s.Deref(a)
+	return v
+}
+
+func copySeen(seen map[string]bool, name string) map[string]bool {
+	c := map[string]bool{name: true}
+	for k := range seen {
+		c[k] = true
+	}
+	return c
+}
+
+// sample example value built from a schema
+func (s *apiSpec) sample(a apiVal, depth int, seen map[string]bool) interface{} {
+	v, name, err := s.Deref(a)
+	if err != nil || depth > 8 || name != "" && seen[name] {
+		return nil
+	}
+	if name != "" {
+		seen = copySeen(seen, name)
+	}
+	if ex := apiGet(v.V, "example"); ex != nil {
+		return ex
+	}
+	if d := apiGet(v.V, "default"); d != nil {
+		return d
+	}
+	if enum := apiList(v.V, "enum"); len(enum) > 0 {
+		return enum[0]
+	}
+	for _, k := range []string{"oneOf", "anyOf"} {
+		if alts := apiList(v.V, k); len(alts) > 0 {
+			return s.sample(apiVal{alts[0], v.File}, depth+1, seen)
+		}
+	}
+
+	props, _ := s.properties(v, seen)
+	t := apiStr(v.V, "type")
+	switch {
+	case len(props) > 0 || t == "object":
+		obj := yaml.MapSlice{}
+		for _, p := range props {
+			obj = append(obj, yaml.MapItem{Key: p.Key, Value: s.sample(p.Value.(apiVal), depth+1, seen)})
+		}
+		return obj
+	case t == "array":
+		return []interface{}{s.sample(apiVal{apiGet(v.V, "items"), v.File}, depth+1, seen)}
+	case t == "integer" || t == "number":
+		return 0
+	case t == "boolean":
+		return true
+	case t == "string":
+		switch apiStr(v.V, "format") {
+		case "date-time":
+			return "2006-01-02T15:04:05Z"
+		case "date":
+			return "2006-01-02"
+		case "email":
+			return "user@example.com"
+		case "uuid":
+			return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+		case "uri", "url":
+			return "https://example.com/"
+		}
+		return "string"
+	}
+	return nil
+}
+
+// apiJSON json text keeping mapping order
+func apiJSON(v interface{}, indent string) string {
+	in := indent + "  "
+	switch c := v.(type) {
+	case yaml.MapSlice, map[string]interface{}:
+		items := apiItems(c)
+		if len(items) == 0 {
+			return "{}"
+		}
+		parts := []string{}
+		for _, it := range items {
+			k, _ := json.Marshal(fmt.Sprint(it.Key))
+			parts = append(parts, in+string(k)+": "+apiJSON(it.Value, in))
+		}
+		return "{\n" + strings.Join(parts, ",\n") + "\n" + indent + "}"
+	case []interface{}:
+		if len(c) == 0 {
+			return "[]"
+		}
+		parts := []string{}
+		for _, e := range c {
+			parts = append(parts, in+apiJSON(e, in))
+		}
+		return "[\n" + strings.Join(parts, ",\n") + "\n" + indent + "]"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprint(v))
+	}
+	return string(b)
+}
+
+// markdown commonmark description (its headings stay out of the toc)
+func (s *apiSpec) markdown(d string) string {
+	if strings.TrimSpace(d) == "" {
+		return ""
+	}
+	desc := page{}
+	b := RenderMarkdown(s.cwd, s.name, []byte(d), nil, &desc)
+	s.pg.Math = s.pg.Math || desc.Math
+	s.pg.Mermaid = s.pg.Mermaid || desc.Mermaid
+	return string(b)
+}
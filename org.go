@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/niklasfasching/go-org/org"
+)
+
+// orgWriter go-org html writer with mkup code blocks and links
+type orgWriter struct {
+	*org.HTMLWriter
+}
+
+// WriteRegularLink keep links to other .org files (go-org rewrites them to .html)
+func (w *orgWriter) WriteRegularLink(l org.RegularLink) {
+	url := strings.TrimPrefix(l.URL, "file:")
+	if (l.Protocol != "file" && l.Protocol != "") || l.Kind() != "regular" || !Match(`(?i)\.org(::.*)?$`, url) {
+		w.HTMLWriter.WriteRegularLink(l)
+		return
+	}
+	url = ReplaceAll(`::.*$`, "", url)
+	desc := html.EscapeString(url)
+	if l.Description != nil {
+		desc = w.WriteNodesAsString(l.Description...)
+	}
+	w.WriteString(fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(url), desc))
+}
+
+// orgDoc org-mode document
+func orgDoc(cwd, name string, b []byte, pg *page) ([]byte, error) {
+	fp := filepath.Join(cwd, name)
+
+	conf := org.New()
+	conf.Log = log.New(ioutil.Discard, "", 0)
+	// 目次はサイドバーに出す
+	conf.DefaultSettings["OPTIONS"] = strings.Replace(conf.DefaultSettings["OPTIONS"], "toc:t", "toc:nil", 1)
+	// #+INCLUDE は公開ディレクトリの中だけ
+	conf.ReadFile = func(fn string) ([]byte, error) {
+		if !insideRoot(cwd, fn) {
+			return nil, fmt.Errorf("%s: outside of the served root", fn)
+		}
+		return ioutil.ReadFile(fn)
+	}
+
+	doc := conf.Parse(bytes.NewReader(b), fp)
+	if doc.Error != nil {
+		return nil, doc.Error
+	}
+	if title := doc.Get("TITLE"); title != "" {
+		pg.Title = title + " - mkup"
+	}
+
+	w := &orgWriter{org.NewHTMLWriter()}
+	w.ExtendingWriter = w
+	w.HighlightCodeBlock = func(source, lang string, inline bool, params map[string]string) string {
+		if inline {
+			return "<code>" + html.EscapeString(source) + "</code>"
+		}
+		return Highlight(source, lang, "", *lineNumbers)
+	}
+	s, err := doc.Write(w)
+	if err != nil {
+		return nil, err
+	}
+	s = orgCheckboxes.Replace(s)
+	return HeadingToc([]byte(s), pg), nil
+}
+
+// orgCheckboxes org checkbox items as github task list items
+var orgCheckboxes = strings.NewReplacer(
+	`<li class="unchecked">`, `<li class="task-list-item">`+taskCheckbox+`> `,
+	`<li class="checked">`, `<li class="task-list-item">`+taskCheckbox+` checked> `,
+	`<li class="indeterminate">`, `<li class="task-list-item">`+taskCheckbox+`> `,
+)
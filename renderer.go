@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"html"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/russross/blackfriday"
+)
+
+// mdRenderer blackfriday html renderer with mkup extensions
+type mdRenderer struct {
+	blackfriday.Renderer
+	ids     map[string]int
+	maths   []mathSpan
+	images  []wikiImage
+	Toc     []tocEntry
+	Mermaid bool
+}
+
+func newMdRenderer(r blackfriday.Renderer) *mdRenderer {
+	return &mdRenderer{Renderer: r, ids: map[string]int{}}
+}
+
+// RenderMarkdown mdview rendering pipeline for markdown of file name.
+// toc, math and mermaid are added to pg, so it can be called per fragment
+func RenderMarkdown(cwd, name string, b []byte, meta map[string]interface{}, pg *page) []byte {
+	b = ExpandIncludes(cwd, filepath.Join(cwd, name), b)
+	b, images := WikiLinks(cwd, name, b)
+
+	// math は blackfriday に渡す前に退避
+	b, maths := ProtectMath(b)
+
+	ext, hf, err := MarkdownFlags(meta)
+	if err != nil {
+		pg.MetaError = err.Error()
+	}
+
+	renderer := newMdRenderer(blackfriday.HtmlRenderer(hf, "", ""))
+	renderer.maths = maths
+	renderer.images = images
+	for _, t := range pg.Toc {
+		renderer.ids[t.ID] = 1
+	}
+	b = blackfriday.Markdown(b, renderer, ext)
+	// math の中は autolink しない (class="math" は MapHTMLText が飛ばす)
+	b = RestoreMath(b, maths)
+	autolinks := Config().Autolinks
+	b = MapHTMLText(b, func(s string) string {
+		return Autolink(Emojize(s), autolinks)
+	})
+	b = Callouts(b)
+
+	for _, t := range renderer.Toc {
+		t.Text = Emojize(t.Text)
+		pg.Toc = append(pg.Toc, t)
+	}
+	pg.Math = pg.Math || len(maths) > 0 && HasAsset("_assets/katex/katex.min.js")
+	pg.Mermaid = pg.Mermaid || renderer.Mermaid
+	return b
+}
+
+// BlockCode fenced code block by language
+func (mr *mdRenderer) BlockCode(out *bytes.Buffer, text []byte, infoString string) {
+	lang := ""
+	if f := strings.Fields(infoString); len(f) > 0 {
+		lang = strings.ToLower(f[0])
+	}
+
+	command, external := Config().Renderers[lang]
+
+	switch {
+	case external:
+		doubleSpace(out)
+		s, err := ExtRender(lang, command, text)
+		if err != nil {
+			out.WriteString(extRenderError(lang, err))
+			out.WriteString(Highlight(string(text), "", "", false))
+			return
+		}
+		out.WriteString("<div class=\"ext-render ext-render-" + html.EscapeString(lang) + "\">\n")
+		out.WriteString(s)
+		out.WriteString("</div>\n")
+	case lang == "mermaid" && HasAsset("_assets/mermaid.min.js"):
+		doubleSpace(out)
+		out.WriteString("<div class=\"mermaid\">")
+		out.WriteString(html.EscapeString(string(text)))
+		out.WriteString("</div>\n")
+		mr.Mermaid = true
+	case lang == "csv" || lang == "tsv":
+		doubleSpace(out)
+		out.WriteString(CSVTable(text, csvComma(lang)))
+	default:
+		doubleSpace(out)
+		linenos := *lineNumbers
+		for _, f := range strings.Fields(infoString) {
+			if f == "linenos" {
+				linenos = true
+			}
+		}
+		out.WriteString(Highlight(string(text), lang, "", linenos))
+	}
+}
+
+func doubleSpace(out *bytes.Buffer) {
+	if out.Len() > 0 {
+		out.WriteByte('\n')
+	}
+}
+
+var fenceRe = regexp.MustCompile("^(```+|~~~+)")
+
+// codeFence fenced code block tracker for line based source scans
+type codeFence struct {
+	marker string
+}
+
+// Skip line is part of a fenced code block (fence lines included)
+func (f *codeFence) Skip(l string) bool {
+	t := strings.TrimLeft(l, " ")
+	if f.marker != "" {
+		if strings.HasPrefix(t, f.marker) && strings.Trim(strings.TrimSpace(t), f.marker[:1]) == "" {
+			f.marker = ""
+		}
+		return true
+	}
+	if m := fenceRe.FindString(t); m != "" && len(l)-len(t) < 4 {
+		f.marker = m
+		return true
+	}
+	return false
+}
+
+// MapText apply fn to markdown text outside code blocks and code spans
+func MapText(b []byte, fn func(string) string) []byte {
+	return mapChunks(b, func(s string) string { return mapSpans(s, fn) })
+}
+
+// mapChunks apply fn to runs of lines outside fenced / indented code blocks
+func mapChunks(b []byte, fn func(string) string) []byte {
+	var out, chunk strings.Builder
+	flush := func() {
+		out.WriteString(fn(chunk.String()))
+		chunk.Reset()
+	}
+
+	fence := codeFence{}
+	prevBlank, inIndent := true, false
+	for _, l := range strings.SplitAfter(string(b), "\n") {
+		blank := strings.TrimSpace(l) == ""
+		if fence.Skip(l) {
+			flush()
+			out.WriteString(l)
+			continue
+		}
+		if !blank && (strings.HasPrefix(l, "    ") || strings.HasPrefix(l, "\t")) && (prevBlank || inIndent) {
+			flush()
+			inIndent = true
+			out.WriteString(l)
+			continue
+		}
+		if !blank {
+			inIndent = false
+		}
+		prevBlank = blank
+		chunk.WriteString(l)
+	}
+	flush()
+	return []byte(out.String())
+}
+
+// mapSpans apply fn between `code spans`
+func mapSpans(s string, fn func(string) string) string {
+	var out strings.Builder
+	for {
+		i := strings.Index(s, "`")
+		if i < 0 {
+			break
+		}
+		n := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+		run := s[i : i+n]
+		j := strings.Index(s[i+n:], run)
+		if j < 0 {
+			out.WriteString(fn(s[:i+n]))
+			s = s[i+n:]
+			continue
+		}
+		out.WriteString(fn(s[:i]))
+		out.WriteString(s[i : i+n+j+n])
+		s = s[i+n+j+n:]
+	}
+	out.WriteString(fn(s))
+	return out.String()
+}
+
+// htmlSkipTags elements whose text is left alone by MapHTMLText
+var htmlSkipTags = map[string]bool{"a": true, "code": true, "pre": true, "script": true, "style": true, "svg": true, "kbd": true}
+
+var (
+	htmlTagRe   = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9]*)([^>]*)>`)
+	htmlSkipCls = regexp.MustCompile(`class="[^"]*\b(mermaid|math)\b`)
+)
+
+// MapHTMLText apply fn to html text nodes outside links, code, svg, math and diagrams
+func MapHTMLText(b []byte, fn func(string) string) []byte {
+	s := string(b)
+	var out strings.Builder
+	skip, depth := "", 0
+	for {
+		m := htmlTagRe.FindStringSubmatchIndex(s)
+		if m == nil {
+			break
+		}
+		text, tag := s[:m[0]], s[m[0]:m[1]]
+		closing, name, attr := s[m[2]:m[3]] == "/", strings.ToLower(s[m[4]:m[5]]), s[m[6]:m[7]]
+
+		if skip == "" {
+			out.WriteString(fn(text))
+			if !closing && (htmlSkipTags[name] || htmlSkipCls.MatchString(attr)) && !strings.HasSuffix(attr, "/") {
+				skip, depth = name, 1
+			}
+		} else {
+			out.WriteString(text)
+			if name == skip {
+				if closing {
+					depth--
+				} else {
+					depth++
+				}
+				if depth == 0 {
+					skip = ""
+				}
+			}
+		}
+		out.WriteString(tag)
+		s = s[m[1]:]
+	}
+	if skip == "" {
+		out.WriteString(fn(s))
+	} else {
+		out.WriteString(s)
+	}
+	return []byte(out.String())
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// tailBacklog bytes from the end sent when a tail starts
+	tailBacklog = 64 << 10
+	// tailChunk most bytes read at once while following
+	tailChunk = 256 << 10
+)
+
+var (
+	tailMu   sync.Mutex
+	tailSubs = map[string]map[chan struct{}]bool{}
+
+	ansiCSIRe = regexp.MustCompile("\x1b\\[([0-9;?]*)([A-Za-z])")
+
+	ansiPalette = []string{
+		"#000000", "#cd3131", "#0dbc79", "#e5e510", "#2472c8", "#bc3fbc", "#11a8cd", "#e5e5e5",
+		"#666666", "#f14c4c", "#23d18b", "#f5f543", "#3b8eea", "#d670d6", "#29b8db", "#ffffff",
+	}
+)
+
+// TailSubscribe channel signalled when fp is written, call the func to stop
+func TailSubscribe(fp string) (chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	tailMu.Lock()
+	if tailSubs[fp] == nil {
+		tailSubs[fp] = map[chan struct{}]bool{}
+	}
+	tailSubs[fp][ch] = true
+	tailMu.Unlock()
+	return ch, func() {
+		tailMu.Lock()
+		delete(tailSubs[fp], ch)
+		if len(tailSubs[fp]) == 0 {
+			delete(tailSubs, fp)
+		}
+		tailMu.Unlock()
+	}
+}
+
+// TailNotify wake the tails of fp (fsnotify write)
+func TailNotify(fp string) {
+	tailMu.Lock()
+	defer tailMu.Unlock()
+	for ch := range tailSubs[fp] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// IsTailed file is shown by the tail view, livereload leaves it alone
+func IsTailed(name string) bool {
+	return strings.ToLower(filepath.Ext(name)) == ".log"
+}
+
+// ansiState SGR attributes carried over lines
+type ansiState struct {
+	fg, bg                     string
+	bold, italic, underline    bool
+	faint, inverse, strikeLine bool
+}
+
+func ansiColor(n int) string {
+	switch {
+	case n < 16:
+		return ansiPalette[n]
+	case n < 232:
+		levels := []int{0, 95, 135, 175, 215, 255}
+		n -= 16
+		return fmt.Sprintf("#%02x%02x%02x", levels[n/36], levels[n/6%6], levels[n%6])
+	case n < 256:
+		g := 8 + 10*(n-232)
+		return fmt.Sprintf("#%02x%02x%02x", g, g, g)
+	}
+	return ""
+}
+
+// sgr apply "1;31" style parameters
+func (st *ansiState) sgr(params string) {
+	ps := strings.Split(params, ";")
+	for i := 0; i < len(ps); i++ {
+		n, _ := strconv.Atoi(ps[i])
+		switch {
+		case n == 0:
+			*st = ansiState{}
+		case n == 1:
+			st.bold = true
+		case n == 2:
+			st.faint = true
+		case n == 3:
+			st.italic = true
+		case n == 4:
+			st.underline = true
+		case n == 7:
+			st.inverse = true
+		case n == 9:
+			st.strikeLine = true
+		case n == 22:
+			st.bold, st.faint = false, false
+		case n == 23:
+			st.italic = false
+		case n == 24:
+			st.underline = false
+		case n == 27:
+			st.inverse = false
+		case n == 29:
+			st.strikeLine = false
+		case n >= 30 && n <= 37:
+			st.fg = ansiPalette[n-30]
+		case n >= 90 && n <= 97:
+			st.fg = ansiPalette[n-90+8]
+		case n >= 40 && n <= 47:
+			st.bg = ansiPalette[n-40]
+		case n >= 100 && n <= 107:
+			st.bg = ansiPalette[n-100+8]
+		case n == 39:
+			st.fg = ""
+		case n == 49:
+			st.bg = ""
+		case (n == 38 || n == 48) && i+1 < len(ps):
+			// 38;5;n 256 色, 38;2;r;g;b
+			c := ""
+			if ps[i+1] == "5" && i+2 < len(ps) {
+				v, _ := strconv.Atoi(ps[i+2])
+				c, i = ansiColor(v), i+2
+			} else if ps[i+1] == "2" && i+4 < len(ps) {
+				r, _ := strconv.Atoi(ps[i+2])
+				g, _ := strconv.Atoi(ps[i+3])
+				b, _ := strconv.Atoi(ps[i+4])
+				c, i = fmt.Sprintf("#%02x%02x%02x", r&255, g&255, b&255), i+4
+			}
+			if n == 38 {
+				st.fg = c
+			} else {
+				st.bg = c
+			}
+		}
+	}
+}
+
+func (st *ansiState) style() string {
+	fg, bg := st.fg, st.bg
+	if st.inverse {
+		fg, bg = bg, fg
+		if fg == "" {
+			fg = "#ffffff"
+		}
+		if bg == "" {
+			bg = "#24292e"
+		}
+	}
+	s := []string{}
+	if fg != "" {
+		s = append(s, "color:"+fg)
+	}
+	if bg != "" {
+		s = append(s, "background-color:"+bg)
+	}
+	if st.bold {
+		s = append(s, "font-weight:bold")
+	}
+	if st.faint {
+		s = append(s, "opacity:0.7")
+	}
+	if st.italic {
+		s = append(s, "font-style:italic")
+	}
+	if st.underline || st.strikeLine {
+		d := []string{}
+		if st.underline {
+			d = append(d, "underline")
+		}
+		if st.strikeLine {
+			d = append(d, "line-through")
+		}
+		s = append(s, "text-decoration:"+strings.Join(d, " "))
+	}
+	return strings.Join(s, ";")
+}
+
+// HTML escaped line with SGR colors as styled spans, other escapes dropped
+func (st *ansiState) HTML(line string) string {
+	var buf strings.Builder
+	text := func(t string) {
+		if t == "" {
+			return
+		}
+		if s := st.style(); s != "" {
+			fmt.Fprintf(&buf, "<span style=\"%s\">%s</span>", s, html.EscapeString(t))
+		} else {
+			buf.WriteString(html.EscapeString(t))
+		}
+	}
+	last := 0
+	for _, m := range ansiCSIRe.FindAllStringSubmatchIndex(line, -1) {
+		text(line[last:m[0]])
+		if line[m[4]:m[5]] == "m" {
+			st.sgr(line[m[2]:m[3]])
+		}
+		last = m[1]
+	}
+	text(line[last:])
+	return buf.String()
+}
+
+// StripANSI text without ANSI escapes
+func StripANSI(s string) string {
+	return ansiCSIRe.ReplaceAllString(s, "")
+}
+
+// tailLine text of a line as a terminal shows it: what comes after the last
+// \r (progress bars), the colors of the overwritten part are kept in st
+func tailLine(st *ansiState, l []byte) string {
+	l = bytes.TrimRight(l, "\r")
+	if i := bytes.LastIndexByte(l, '\r'); i >= 0 {
+		st.HTML(string(l[:i]))
+		l = l[i+1:]
+	}
+	return string(l)
+}
+
+// logview tail page of a log file, lines come from /_tail/
+func logview(cwd string, w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Path
+	if _, err := os.Stat(filepath.Join(cwd, name)); err != nil {
+		http.Error(w, "404 page not found", 404)
+		return
+	}
+
+	pg := page{}
+	pg.Title = filepath.Base(name) + " - mkup"
+
+	// 階層メニュー Dirnests
+	rd := filepath.Dir(name)
+	MenuDir(rd, &pg)
+
+	var buf bytes.Buffer
+	buf.WriteString("<ul class=\"sheet-tabs\">\n<li class=\"active\"><a href=\"?\">tail</a></li>\n<li><a href=\"?view=source\">file</a></li>\n</ul>\n")
+	fmt.Fprintf(&buf, "<div class=\"tail-view\" data-src=\"%s\">\n", html.EscapeString((&url.URL{Path: "/_tail" + name}).String()))
+	buf.WriteString("<p class=\"tail-tools\"><label><input type=\"checkbox\" class=\"tail-follow\" checked> follow</label> " +
+		"<button class=\"tail-pause\">pause</button> " +
+		"<input type=\"text\" class=\"tail-filter\" placeholder=\"regex filter\"> " +
+		"<span class=\"tail-status\"></span></p>\n")
+	buf.WriteString("<pre class=\"tail-lines\"><code></code></pre>\n</div>\n")
+
+	renderPage(w, pg, buf.Bytes())
+	return
+}
+
+// tailstream server-sent events of the lines of a file: the last part first,
+// then what is appended; "reset" when the file was truncated
+func tailstream(cwd string, w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/_tail")
+	fp := filepath.Join(cwd, name)
+	f, err := os.Open(fp)
+	if err != nil {
+		http.Error(w, "404 page not found", 404)
+		return
+	}
+	defer f.Close()
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", 500)
+		return
+	}
+
+	ch, stop := TailSubscribe(fp)
+	defer stop()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	info, err := f.Stat()
+	if err != nil {
+		return
+	}
+	// 最後の部分から (行の途中は捨てる)
+	off := info.Size() - tailBacklog
+	skip := off > 0
+	if off < 0 {
+		off = 0
+	}
+	st := ansiState{}
+	partial := []byte{}
+	buf := make([]byte, tailChunk)
+	send := func() bool {
+		info, err := f.Stat()
+		if err != nil {
+			return false
+		}
+		if info.Size() < off {
+			off, partial, st = 0, partial[:0], ansiState{}
+			fmt.Fprint(w, "event: reset\ndata: \n\n")
+		}
+		for off < info.Size() {
+			n, err := f.ReadAt(buf, off)
+			if n == 0 && err != nil && err != io.EOF {
+				return false
+			}
+			if n == 0 {
+				break
+			}
+			off += int64(n)
+			b := append(partial, buf[:n]...)
+			if skip {
+				if i := bytes.IndexByte(b, '\n'); i >= 0 {
+					b, skip = b[i+1:], false
+				}
+			}
+			lines := bytes.Split(b, []byte("\n"))
+			last := lines[len(lines)-1]
+			lines = lines[:len(lines)-1]
+			// 長すぎる行はそこで区切る
+			if len(last) > tailChunk {
+				lines, last = append(lines, last), nil
+			}
+			partial = append([]byte{}, last...)
+			for _, l := range lines {
+				fmt.Fprintf(w, "data: %s\n\n", st.HTML(tailLine(&st, l)))
+			}
+		}
+		flusher.Flush()
+		return true
+	}
+	if !send() {
+		return
+	}
+
+	// fsnotify を取りこぼしても拾えるように
+	tick := time.NewTicker(2 * time.Second)
+	defer tick.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ch:
+		case <-tick.C:
+		}
+		if !send() {
+			return
+		}
+	}
+}
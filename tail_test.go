@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTailLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"plain", "plain"},
+		{"done\r", "done"},
+		{"10%\r50%\r100%", "100%"},
+		{"10%\r50%\r100%\r", "100%"},
+		{"overwritten\r", "overwritten"},
+		{"\r", ""},
+	}
+	for _, tt := range tests {
+		st := ansiState{}
+		if got := tailLine(&st, []byte(tt.line)); got != tt.want {
+			t.Errorf("tailLine(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+
+	// 上書きされた部分の色は残る
+	st := ansiState{}
+	if got := st.HTML(tailLine(&st, []byte("\x1b[31mred\rafter"))); got != `<span style="color:#cd3131">after</span>` {
+		t.Errorf("colors before \\r lost: %q", got)
+	}
+}
+
+func TestANSIHTML(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"a < b", "a &lt; b"},
+		{"\x1b[1;31mx\x1b[0m y", `<span style="color:#cd3131;font-weight:bold">x</span> y`},
+		{"\x1b[38;5;196mx", `<span style="color:#ff0000">x</span>`},
+		{"\x1b[48;2;1;2;3mx", `<span style="background-color:#010203">x</span>`},
+		{"\x1b[7mx", `<span style="color:#ffffff;background-color:#24292e">x</span>`},
+		// 色以外のエスケープは捨てる
+		{"\x1b[2K\x1b[1Gtext", "text"},
+	}
+	for _, tt := range tests {
+		st := ansiState{}
+		if got := st.HTML(tt.line); got != tt.want {
+			t.Errorf("HTML(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestStripANSI(t *testing.T) {
+	tb := "\x1b[0;31mValueError\x1b[0m: bad \x1b[?25lvalue\x1b[1;32m"
+	if got, want := StripANSI(tb), "ValueError: bad value"; got != want {
+		t.Errorf("StripANSI(%q) = %q, want %q", tb, got, want)
+	}
+}
+
+// tailEvents events of a tail stream, "reset" or the data of a line
+func tailEvents(t *testing.T, url string) (<-chan string, func()) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan string, 100)
+	go func() {
+		defer close(ch)
+		s := bufio.NewScanner(resp.Body)
+		s.Buffer(nil, tailChunk*2)
+		for s.Scan() {
+			l := s.Text()
+			switch {
+			case l == "event: reset":
+				ch <- "reset"
+				s.Scan()
+			case strings.HasPrefix(l, "data: "):
+				ch <- strings.TrimPrefix(l, "data: ")
+			}
+		}
+	}()
+	return ch, func() { resp.Body.Close() }
+}
+
+func expectEvents(t *testing.T, ch <-chan string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		select {
+		case got := <-ch:
+			if got != w {
+				t.Fatalf("event %q, want %q", got, w)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no event, want %q", w)
+		}
+	}
+}
+
+func TestTailStream(t *testing.T) {
+	cwd := t.TempDir()
+	fp := filepath.Join(cwd, "a.log")
+	if err := ioutil.WriteFile(fp, []byte("one\ntwo\npart"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tailstream(cwd, w, r)
+	}))
+	defer s.Close()
+
+	ch, stop := tailEvents(t, s.URL+"/_tail/a.log")
+	defer stop()
+	expectEvents(t, ch, "one", "two")
+
+	// 行の途中は改行が来てから
+	appendFile(t, fp, "ial\nthree\n")
+	TailNotify(fp)
+	expectEvents(t, ch, "partial", "three")
+
+	// 切り詰められたら最初から
+	if err := ioutil.WriteFile(fp, []byte("new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	TailNotify(fp)
+	expectEvents(t, ch, "reset", "new")
+}
+
+func TestTailStreamBacklog(t *testing.T) {
+	cwd := t.TempDir()
+	fp := filepath.Join(cwd, "big.log")
+	var b strings.Builder
+	for i := 1; b.Len() < 3*tailBacklog; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	src := b.String()
+	if err := ioutil.WriteFile(fp, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tailstream(cwd, w, r)
+	}))
+	defer s.Close()
+
+	// 最後の 64 KiB の, 途中から始まる最初の行は飛ばす
+	rest := src[len(src)-tailBacklog:]
+	rest = rest[strings.IndexByte(rest, '\n')+1:]
+	want := strings.Split(strings.TrimSuffix(rest, "\n"), "\n")
+
+	ch, stop := tailEvents(t, s.URL+"/_tail/big.log")
+	defer stop()
+	expectEvents(t, ch, want...)
+}
+
+func appendFile(t *testing.T, fp, s string) {
+	t.Helper()
+	f, err := os.OpenFile(fp, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(s); err != nil {
+		t.Fatal(err)
+	}
+}
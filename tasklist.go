@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/russross/blackfriday"
+)
+
+const taskCheckbox = `<input type="checkbox" class="task-list-item-checkbox" disabled`
+
+var (
+	taskItemRe   = regexp.MustCompile(`^(<p>)?\[([ xX])\](\s|$)`)
+	taskSourceRe = regexp.MustCompile(`^\s*(?:>\s*)*(?:[-*+]|\d+[.)])\s+\[([ xX])\](?:\s|$)`)
+	taskMu       sync.Mutex
+)
+
+// ListItem github style task list item
+func (mr *mdRenderer) ListItem(out *bytes.Buffer, text []byte, flags int) {
+	m := taskItemRe.FindSubmatchIndex(text)
+	if m == nil || flags&(blackfriday.LIST_TYPE_TERM|blackfriday.LIST_TYPE_DEFINITION) != 0 {
+		mr.Renderer.ListItem(out, text, flags)
+		return
+	}
+
+	box := taskCheckbox
+	if text[m[4]] != ' ' {
+		box += " checked"
+	}
+	item := []byte{}
+	if m[2] >= 0 {
+		item = append(item, "<p>"...)
+	}
+	item = append(item, box+"> "...)
+	item = append(item, text[m[1]:]...)
+
+	marker := out.Len()
+	mr.Renderer.ListItem(out, item, flags)
+	li := bytes.Index(out.Bytes()[marker:], []byte("<li>"))
+	if li >= 0 {
+		rest := append([]byte(`<li class="task-list-item">`), out.Bytes()[marker+li+4:]...)
+		out.Truncate(marker + li)
+		out.Write(rest)
+	}
+}
+
+// TaskLines source line numbers (1-based) of task list items, document order
+func TaskLines(src []byte, offset int) []int {
+	lines := []int{}
+	fence := codeFence{}
+	for i, l := range strings.Split(string(src), "\n") {
+		if fence.Skip(l) {
+			continue
+		}
+		if taskSourceRe.MatchString(l) {
+			lines = append(lines, offset+i+1)
+		}
+	}
+	return lines
+}
+
+// NumberTasks add data-line to rendered checkboxes, only when source and html agree
+func NumberTasks(b []byte, lines []int) []byte {
+	if bytes.Count(b, []byte(taskCheckbox)) != len(lines) {
+		return b
+	}
+	parts := bytes.Split(b, []byte(taskCheckbox))
+	var buf bytes.Buffer
+	buf.Write(parts[0])
+	for i, p := range parts[1:] {
+		fmt.Fprintf(&buf, "%s data-line=\"%d\"", taskCheckbox, lines[i])
+		buf.Write(p)
+	}
+	return buf.Bytes()
+}
+
+// FileETag etag from mtime and size
+func FileETag(info os.FileInfo) string {
+	return fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size())
+}
+
+// tasktoggle flip a task list item in the source file (-writable only)
+func tasktoggle(cwd string, w http.ResponseWriter, r *http.Request) {
+	if !*writable || r.Method != "POST" {
+		http.Error(w, "403 forbidden", 403)
+		return
+	}
+	name := ReplaceAll("^/_task", "", r.URL.Path)
+	if !Match(`(?i)\.(md|markdown|mkd)$`, name) {
+		http.Error(w, "403 forbidden", 403)
+		return
+	}
+	fp := filepath.Join(cwd, filepath.FromSlash(name))
+
+	line, err := strconv.Atoi(r.FormValue("line"))
+	if err != nil || line < 1 {
+		http.Error(w, "400 bad request", 400)
+		return
+	}
+	checked := r.FormValue("checked") == "true"
+
+	taskMu.Lock()
+	defer taskMu.Unlock()
+
+	info, err := os.Stat(fp)
+	if err != nil {
+		http.Error(w, "404 page not found", 404)
+		return
+	}
+	// エディタ側の更新を上書きしない
+	if r.Header.Get("If-Match") != FileETag(info) {
+		http.Error(w, "file was modified, reload the page", http.StatusPreconditionFailed)
+		return
+	}
+
+	b, err := ioutil.ReadFile(fp)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	lines := strings.SplitAfter(string(b), "\n")
+	if line > len(lines) {
+		http.Error(w, "409 line not found", 409)
+		return
+	}
+	m := taskSourceRe.FindStringSubmatchIndex(lines[line-1])
+	if m == nil {
+		http.Error(w, "409 not a task list item", 409)
+		return
+	}
+	mark := " "
+	if checked {
+		mark = "x"
+	}
+	l := lines[line-1]
+	lines[line-1] = l[:m[2]] + mark + l[m[3]:]
+
+	err = ioutil.WriteFile(fp, []byte(strings.Join(lines, "")), info.Mode())
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	if info, err = os.Stat(fp); err == nil {
+		w.Header().Set("ETag", FileETag(info))
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return
+}
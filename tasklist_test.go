@@ -0,0 +1,212 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var dataLineRe = regexp.MustCompile(`task-list-item-checkbox" disabled data-line="(\d+)"( checked)?`)
+
+func TestTaskLines(t *testing.T) {
+	src := strings.Join([]string{
+		"# tasks",                // 1
+		"",                       // 2
+		"- [ ] one",              // 3
+		"* [x] two",              // 4
+		"",                       // 5
+		"```",                    // 6
+		"- [ ] in a fence",       // 7
+		"```",                    // 8
+		"1. [X] three",           // 9
+		"> - [ ] quoted",         // 10
+		"- [] not a task",        // 11
+		"~~~md",                  // 12
+		"- [x] in a tilde fence", // 13
+		"~~~",                    // 14
+		"  + [ ] nested",         // 15
+	}, "\n")
+
+	if got, want := TaskLines([]byte(src), 0), []int{3, 4, 9, 10, 15}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TaskLines = %v, want %v", got, want)
+	}
+	// front matter の分ずらす
+	if got, want := TaskLines([]byte(src), 4), []int{7, 8, 13, 14, 19}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TaskLines offset 4 = %v, want %v", got, want)
+	}
+}
+
+func TestNumberTasks(t *testing.T) {
+	b := []byte("<li>" + taskCheckbox + "> a</li><li>" + taskCheckbox + " checked> b</li>")
+
+	got := string(NumberTasks(b, []int{3, 7}))
+	want := "<li>" + taskCheckbox + ` data-line="3"> a</li><li>` + taskCheckbox + ` data-line="7" checked> b</li>`
+	if got != want {
+		t.Errorf("NumberTasks = %q, want %q", got, want)
+	}
+
+	// 数が合わなければ付けない
+	if got := NumberTasks(b, []int{3}); string(got) != string(b) {
+		t.Errorf("NumberTasks with a missing line = %q, want unchanged", got)
+	}
+}
+
+// renderTasks data-line of each rendered checkbox and whether it is checked
+func renderTasks(t *testing.T, cwd, name string) map[int]bool {
+	t.Helper()
+	b, err := ioutil.ReadFile(filepath.Join(cwd, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pg := page{}
+	out, err := markdownDoc(cwd, "/"+name, b, &pg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks := map[int]bool{}
+	for _, m := range dataLineRe.FindAllStringSubmatch(string(out), -1) {
+		n, _ := strconv.Atoi(m[1])
+		tasks[n] = m[2] != ""
+	}
+	return tasks
+}
+
+func TestMarkdownDocTaskLines(t *testing.T) {
+	cwd := t.TempDir()
+	src := strings.Join([]string{
+		"---",              // 1
+		"title: tasks",     // 2
+		"tags: [a, b]",     // 3
+		"---",              // 4
+		"# tasks",          // 5
+		"",                 // 6
+		"- [ ] one",        // 7
+		"- [x] two",        // 8
+		"",                 // 9
+		"```markdown",      // 10
+		"- [ ] not a task", // 11
+		"```",              // 12
+		"",                 // 13
+		"1. [ ] three",     // 14
+		"   - [x] nested",  // 15
+		"",                 // 16
+	}, "\n")
+	if err := ioutil.WriteFile(filepath.Join(cwd, "a.md"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := renderTasks(t, cwd, "a.md")
+	want := map[int]bool{7: false, 8: true, 14: false, 15: true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rendered tasks = %v, want %v", got, want)
+	}
+	// data-line は本当にその行のタスクを指す
+	lines := strings.Split(src, "\n")
+	for n := range got {
+		if !taskSourceRe.MatchString(lines[n-1]) {
+			t.Errorf("line %d is not a task: %q", n, lines[n-1])
+		}
+	}
+}
+
+func TestMarkdownDocTaskIncluded(t *testing.T) {
+	cwd := t.TempDir()
+	files := map[string]string{
+		"part.md": "- [ ] included\n- [x] included too\n",
+		"a.md":    "- [ ] own\n\n<!-- include: part.md -->\n\n- [x] after\n",
+	}
+	for name, s := range files {
+		if err := ioutil.WriteFile(filepath.Join(cwd, name), []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, _ := ioutil.ReadFile(filepath.Join(cwd, "a.md"))
+	pg := page{}
+	out, err := markdownDoc(cwd, "/a.md", b, &pg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(out), taskCheckbox); n != 4 {
+		t.Fatalf("%d checkboxes rendered, want 4 (included ones too)", n)
+	}
+	// 取り込んだ行は a.md の行と対応しないので番号を付けない
+	if got := renderTasks(t, cwd, "a.md"); len(got) != 0 {
+		t.Errorf("rendered tasks = %v, want none numbered", got)
+	}
+}
+
+func TestTaskToggle(t *testing.T) {
+	defer func(w bool) { *writable = w }(*writable)
+	*writable = true
+
+	cwd := t.TempDir()
+	fp := filepath.Join(cwd, "a.md")
+	src := "---\ntitle: t\n---\n- [ ] one\n- [x] two\n"
+	if err := ioutil.WriteFile(fp, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// ページを読んだのは少し前
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(fp, old, old); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	toggle := func(line, checked, etag string) *httptest.ResponseRecorder {
+		form := url.Values{"line": {line}, "checked": {checked}}
+		r := httptest.NewRequest("POST", "/_task/a.md", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("If-Match", etag)
+		w := httptest.NewRecorder()
+		tasktoggle(cwd, w, r)
+		return w
+	}
+
+	// 古い ETag は 412 でファイルはそのまま
+	w := toggle("4", "true", `"stale"`)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("stale If-Match: status %d, want %d", w.Code, http.StatusPreconditionFailed)
+	}
+	if b, _ := ioutil.ReadFile(fp); string(b) != src {
+		t.Errorf("file changed on a stale If-Match: %q", b)
+	}
+
+	before := FileETag(info)
+	w = toggle("4", "true", before)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status %d, want %d: %s", w.Code, http.StatusNoContent, w.Body)
+	}
+	want := "---\ntitle: t\n---\n- [x] one\n- [x] two\n"
+	if b, _ := ioutil.ReadFile(fp); string(b) != want {
+		t.Errorf("file = %q, want %q", b, want)
+	}
+	info, _ = os.Stat(fp)
+	if got := w.Header().Get("ETag"); got != FileETag(info) {
+		t.Errorf("ETag = %s, want %s", got, FileETag(info))
+	}
+
+	// 書き換える前の ETag をもう一度送ると 412 (別のタブが古いページから送ったとき)
+	if w := toggle("5", "false", before); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("old ETag: status %d, want %d", w.Code, http.StatusPreconditionFailed)
+	}
+	if b, _ := ioutil.ReadFile(fp); string(b) != want {
+		t.Errorf("file changed on an old ETag: %q", b)
+	}
+	// タスクでない行
+	if w := toggle("1", "true", FileETag(info)); w.Code != http.StatusConflict {
+		t.Errorf("front matter line: status %d, want %d", w.Code, http.StatusConflict)
+	}
+}
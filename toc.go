@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// tocEntry table of contents item
+type tocEntry struct {
+	Level int
+	ID    string
+	Text  string
+}
+
+// Header heading with id and permalink
+func (tr *mdRenderer) Header(out *bytes.Buffer, text func() bool, level int, id string) {
+	marker := out.Len()
+	doubleSpace(out)
+
+	start := out.Len()
+	if !text() {
+		out.Truncate(marker)
+		return
+	}
+	body := string(out.Bytes()[start:])
+	out.Truncate(start)
+
+	plain := html.UnescapeString(ReplaceAll(`<[^>]*>`, "", body))
+	plain = RestoreMathText(plain, tr.maths)
+	if id == "" {
+		id = HeadingID(plain)
+	}
+	id = tr.uniqueID(id)
+
+	fmt.Fprintf(out, "<h%d id=\"%s\"><a class=\"anchor\" href=\"#%s\" aria-hidden=\"true\">&para;</a>%s</h%d>\n",
+		level, html.EscapeString(id), html.EscapeString(id), body, level)
+	tr.Toc = append(tr.Toc, tocEntry{level, id, strings.TrimSpace(plain)})
+}
+
+func (tr *mdRenderer) uniqueID(id string) string {
+	n, found := tr.ids[id]
+	tr.ids[id] = n + 1
+	if !found {
+		return id
+	}
+	for {
+		nid := id + "-" + strconv.Itoa(n)
+		if _, dup := tr.ids[nid]; !dup {
+			tr.ids[nid] = 1
+			return nid
+		}
+		n++
+	}
+}
+
+// HeadingID heading text to anchor id (github style, keeps japanese)
+func HeadingID(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r):
+			b.WriteRune(r)
+		case r == '-' || r == '_':
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune('-')
+		}
+	}
+	if b.Len() == 0 {
+		return "section"
+	}
+	return b.String()
+}
+
+var (
+	htmlHeadingRe = regexp.MustCompile(`(?is)<h([1-6])(\s[^>]*)?>(.*?)</h[1-6]>`)
+	htmlIDRe      = regexp.MustCompile(`\sid="([^"]*)"`)
+)
+
+// HeadingToc anchors and toc for headings of html from other formats (org, rst ...)
+func HeadingToc(b []byte, pg *page) []byte {
+	tr := newMdRenderer(nil)
+	for _, t := range pg.Toc {
+		tr.ids[t.ID] = 1
+	}
+	// rst2html は section の div に id を付ける
+	for _, m := range htmlIDRe.FindAllSubmatch(htmlHeadingRe.ReplaceAll(b, nil), -1) {
+		tr.ids[html.UnescapeString(string(m[1]))] = 1
+	}
+	b = htmlHeadingRe.ReplaceAllFunc(b, func(h []byte) []byte {
+		m := htmlHeadingRe.FindSubmatch(h)
+		level := int(m[1][0] - '0')
+		attr, body := string(m[2]), string(m[3])
+		plain := strings.Join(strings.Fields(html.UnescapeString(ReplaceAll(`<[^>]*>`, "", body))), " ")
+		if plain == "" || strings.Contains(body, `class="anchor"`) {
+			return h
+		}
+
+		id := ""
+		if im := htmlIDRe.FindStringSubmatch(attr); im != nil {
+			id = html.UnescapeString(im[1])
+			tr.ids[id]++
+		} else {
+			id = tr.uniqueID(HeadingID(plain))
+			attr = fmt.Sprintf(" id=\"%s\"", html.EscapeString(id)) + attr
+		}
+		tr.Toc = append(tr.Toc, tocEntry{level, id, plain})
+		return []byte(fmt.Sprintf("<h%d%s><a class=\"anchor\" href=\"#%s\" aria-hidden=\"true\">&para;</a>%s</h%d>",
+			level, attr, html.EscapeString(id), body, level))
+	})
+	pg.Toc = append(pg.Toc, tr.Toc...)
+	return b
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHeadingID(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Hello World", "hello-world"},
+		{"  Trim me  ", "trim-me"},
+		{"C++ & Go!", "c--go"},
+		{"snake_case-and-dash", "snake_case-and-dash"},
+		{"日本語の見出し", "日本語の見出し"},
+		{"Café Crème", "café-crème"},
+		{"été", "été"},
+		{"Version 1.2.3", "version-123"},
+		{"!!!", "section"},
+		{"", "section"},
+	}
+	for _, tt := range tests {
+		if got := HeadingID(tt.text); got != tt.want {
+			t.Errorf("HeadingID(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func tocIDs(toc []tocEntry) []string {
+	ids := []string{}
+	for _, e := range toc {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestHeaderUniqueIDs(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"# A\n# A\n# A", []string{"a", "a-1", "a-2"}},
+		// 付けた番号と同じ見出しがあってもぶつからない
+		{"# A\n# A\n# A-1\n# A", []string{"a", "a-1", "a-1-1", "a-2"}},
+		{"# 概要\n## 概要\n### 概要", []string{"概要", "概要-1", "概要-2"}},
+		{"# !!!\n# ???", []string{"section", "section-1"}},
+	}
+	for _, tt := range tests {
+		pg := page{}
+		RenderMarkdown(t.TempDir(), "/a.md", []byte(tt.src), nil, &pg)
+		if got := tocIDs(pg.Toc); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: ids %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestHeadingToc(t *testing.T) {
+	pg := page{}
+	got := string(HeadingToc([]byte(`<h2>Intro</h2><h2 id="keep">Kept</h2><h3>Intro</h3><div id="intro-1"></div><h2>Intro</h2>`), &pg))
+
+	if ids, want := tocIDs(pg.Toc), []string{"intro", "keep", "intro-2", "intro-3"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ids %q, want %q", ids, want)
+	}
+	if want := `<h2 id="keep">`; !strings.Contains(got, want) {
+		t.Errorf("existing id not kept: %s", got)
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	wikiLinkRe  = regexp.MustCompile(`(!?)\[\[([^\[\]\n]+?)\]\]`)
+	wikiEmbedRe = regexp.MustCompile(`^\s*!\[\[([^\[\]\n]+?)\]\]\s*$`)
+	wikiSizeRe  = regexp.MustCompile(`^(\d+)(?:x(\d+))?$`)
+	imageExtRe  = regexp.MustCompile(`(?i)\.(jpe?g|gif|png|svg|webp|avif|bmp)$`)
+)
+
+// wikiTarget [[name#heading|alias]]
+type wikiTarget struct {
+	Name    string
+	Heading string
+	Alias   string
+}
+
+func parseWikiTarget(s string) wikiTarget {
+	t := wikiTarget{}
+	s = strings.Replace(s, `\|`, "|", -1)
+	if i := strings.Index(s, "|"); i >= 0 {
+		s, t.Alias = s[:i], strings.TrimSpace(s[i+1:])
+	}
+	if i := strings.Index(s, "#"); i >= 0 {
+		s, t.Heading = s[:i], strings.TrimSpace(s[i+1:])
+	}
+	t.Name = strings.TrimSpace(s)
+	return t
+}
+
+// ResolveWiki find a file for a wiki link name, relative to root ("" when missing).
+// same directory as the current page first, then the shortest path
+func ResolveWiki(cwd, page, name string) string {
+	name = strings.TrimPrefix(name, "/")
+	cands := files.Lookup(cwd, path.Base(name))
+	dir := path.Dir(strings.TrimPrefix(page, "/"))
+
+	best := ""
+	for _, c := range cands {
+		if !wikiMatch(c, name) {
+			continue
+		}
+		switch {
+		case best == "":
+			best = c
+		case path.Dir(c) == dir && path.Dir(best) != dir:
+			best = c
+		case (path.Dir(c) == dir) == (path.Dir(best) == dir) && strings.Count(c, "/") < strings.Count(best, "/"):
+			best = c
+		}
+	}
+	return best
+}
+
+// wikiMatch path c is the file for name ("dir/Page" matches "a/dir/Page.md")
+func wikiMatch(c, name string) bool {
+	c, name = strings.ToLower(c), strings.ToLower(name)
+	for _, s := range []string{c, strings.TrimSuffix(c, path.Ext(c))} {
+		if s == name || strings.HasSuffix(s, "/"+name) {
+			return true
+		}
+	}
+	return false
+}
+
+func wikiHref(rel, heading string) string {
+	u := &url.URL{Path: "/" + rel}
+	s := u.EscapedPath()
+	if heading != "" {
+		if strings.HasPrefix(heading, "^") {
+			s += "#" + url.PathEscape(heading[1:])
+		} else {
+			s += "#" + url.PathEscape(HeadingID(heading))
+		}
+	}
+	return s
+}
+
+// wikiMissing link target of a wiki link that resolves to no file,
+// mdRenderer.Link shows it as missing
+const wikiMissing = "mkup:wikilink-missing"
+
+// wikiImage ![[image.png|200x100]] size, applied by mdRenderer.Image
+type wikiImage struct {
+	Src  string
+	Size string
+}
+
+var mdEscapeRe = regexp.MustCompile("[\\\\`*_{}\\[\\]()#+\\-.!:|&<>~]")
+
+// mdEscape text as literal markdown
+func mdEscape(s string) string {
+	return mdEscapeRe.ReplaceAllString(s, `\$0`)
+}
+
+// inLinkText s[from:to] is inside the text of a markdown link, [see s](url)
+func inLinkText(s string, from, to int) bool {
+	// 囲んでいる [ を探す
+	open, depth := -1, 0
+	for i := from - 1; i >= 0 && open < 0; i-- {
+		switch {
+		case i > 0 && s[i-1] == '\\':
+		case s[i] == '\n' && i > 0 && s[i-1] == '\n':
+			return false
+		case s[i] == ']':
+			depth++
+		case s[i] == '[' && depth > 0:
+			depth--
+		case s[i] == '[':
+			open = i
+		}
+	}
+	if open < 0 {
+		return false
+	}
+	// 対応する ] の後が ( か [ ならリンク
+	for i := to; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case s[i] == '\n' && i+1 < len(s) && s[i+1] == '\n':
+			return false
+		case s[i] == '[':
+			depth++
+		case s[i] == ']' && depth > 0:
+			depth--
+		case s[i] == ']':
+			return i+1 < len(s) && (s[i+1] == '(' || s[i+1] == '[')
+		}
+	}
+	return false
+}
+
+// WikiLinks [[Page]], [[Page#Heading|alias]], ![[image.png]] to markdown links
+// and images, sizes of embedded images are returned for the renderer
+func WikiLinks(cwd, page string, b []byte) ([]byte, []wikiImage) {
+	if !strings.Contains(string(b), "[[") {
+		return b, nil
+	}
+	images := []wikiImage{}
+	b = MapText(b, func(s string) string {
+		var out strings.Builder
+		last := 0
+		for _, m := range wikiLinkRe.FindAllStringSubmatchIndex(s, -1) {
+			out.WriteString(s[last:m[0]])
+			last = m[1]
+			// リンクの中の [[...]] はそのまま
+			if inLinkText(s, m[0], m[1]) {
+				out.WriteString(s[m[0]:m[1]])
+				continue
+			}
+			embed := m[3] > m[2]
+			t := parseWikiTarget(s[m[4]:m[5]])
+
+			text := t.Alias
+			if text == "" || embed {
+				text = t.Name
+				if t.Heading != "" && text != "" {
+					text += " > "
+				}
+				text += t.Heading
+			}
+
+			rel := strings.TrimPrefix(page, "/")
+			if t.Name != "" {
+				rel = ResolveWiki(cwd, page, t.Name)
+			}
+			if rel == "" {
+				fmt.Fprintf(&out, "[%s](%s)", mdEscape(text), wikiMissing)
+				continue
+			}
+
+			href := wikiHref(rel, t.Heading)
+			if embed && imageExtRe.MatchString(rel) {
+				if sz := wikiSizeRe.FindStringSubmatch(t.Alias); sz != nil {
+					size := fmt.Sprintf(` width="%s"`, sz[1])
+					if sz[2] != "" {
+						size += fmt.Sprintf(` height="%s"`, sz[2])
+					}
+					images = append(images, wikiImage{href, size})
+				}
+				fmt.Fprintf(&out, "![%s](%s)", t.Name, href)
+				continue
+			}
+			fmt.Fprintf(&out, "[%s](%s)", mdEscape(text), href)
+		}
+		out.WriteString(s[last:])
+		return out.String()
+	})
+	return b, images
+}
+
+// Link wiki links to missing files are shown as text
+func (mr *mdRenderer) Link(out *bytes.Buffer, link []byte, title []byte, content []byte) {
+	if string(link) != wikiMissing {
+		mr.Renderer.Link(out, link, title, content)
+		return
+	}
+	out.WriteString(`<span class="wikilink wikilink-missing" title="not found">`)
+	out.Write(content)
+	out.WriteString("</span>")
+}
+
+// Image with the size of an embedded wiki image
+func (mr *mdRenderer) Image(out *bytes.Buffer, link []byte, title []byte, alt []byte) {
+	if len(mr.images) == 0 || mr.images[0].Src != string(link) {
+		mr.Renderer.Image(out, link, title, alt)
+		return
+	}
+	size := mr.images[0].Size
+	mr.images = mr.images[1:]
+
+	var img bytes.Buffer
+	mr.Renderer.Image(&img, link, title, alt)
+	// 最後の属性の後に足す
+	b := img.Bytes()
+	if i := bytes.LastIndexByte(b, '"'); i >= 0 {
+		out.Write(b[:i+1])
+		out.WriteString(size)
+		out.Write(b[i+1:])
+	}
+}
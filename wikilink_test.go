@@ -0,0 +1,69 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWikiLinks(t *testing.T) {
+	cwd := t.TempDir()
+	for _, name := range []string{"Other Note.md", "pic.png", "sub/Other Note.md", "sub/Deep.md"} {
+		os.MkdirAll(filepath.Dir(filepath.Join(cwd, name)), 0755)
+		if err := ioutil.WriteFile(filepath.Join(cwd, name), []byte("# My Heading\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		src  string
+		meta map[string]interface{}
+		want string
+	}{
+		{"[[Other Note]]", nil, `<a href="/Other%20Note.md">Other Note</a>`},
+		{"[[Deep]]", nil, `<a href="/sub/Deep.md">Deep</a>`},
+		{"[[Other Note#My Heading|alias *x*]]", nil, `<a href="/Other%20Note.md#my-heading">alias *x*</a>`},
+		{"[[Other Note#My Heading]]", nil, `<a href="/Other%20Note.md#my-heading">Other Note &gt; My Heading</a>`},
+		// リンクの中はそのまま
+		{"[see [[Other Note]]](http://x)", nil, `<a href="http://x">see [[Other Note]]</a>`},
+		{"[a [b] [[Other Note]]] text", nil, `[a [b] <a href="/Other%20Note.md">Other Note</a>] text`},
+		{"[[Missing|a<b>]]", nil, `<span class="wikilink wikilink-missing" title="not found">a&lt;b&gt;</span>`},
+		{"![[pic.png|200x100]]", nil, `<img src="/pic.png" alt="pic.png" width="200" height="100">`},
+		{"![[pic.png]]", nil, `<img src="/pic.png" alt="pic.png">`},
+		{"| a |\n|---|\n| [[Other Note\\|al]] |", nil, `<td><a href="/Other%20Note.md">al</a></td>`},
+		{"`[[Other Note]]`", nil, `<code>[[Other Note]]</code>`},
+		// skip_html でも消えない
+		{"[[Other Note]] <b>x</b>", map[string]interface{}{"html_flags": "+skip_html"}, `<a href="/Other%20Note.md">Other Note</a> x`},
+	}
+	for _, tt := range tests {
+		pg := page{}
+		got := string(RenderMarkdown(cwd, "/a.md", []byte(tt.src), tt.meta, &pg))
+		if !strings.Contains(got, tt.want) {
+			t.Errorf("%q:\n got %s\nwant %s", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestInLinkText(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"[see [[X]]](u)", true},
+		{"[see [[X]]][ref]", true},
+		{"[see [[X]]]", false},
+		{"[[X]]", false},
+		{"[a](u) [[X]] [b](u)", false},
+		{"[a [b](u) [[X]] c](u)", true},
+		{"\\[see [[X]]](u)", false},
+		{"[see\n\n[[X]]](u)", false},
+	}
+	for _, tt := range tests {
+		i := strings.Index(tt.s, "[[X]]")
+		if got := inLinkText(tt.s, i, i+5); got != tt.want {
+			t.Errorf("inLinkText(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxPageRows rows per page of xlsxview
+const xlsxPageRows = 500
+
+// xlsxMerge merged cell range clipped to the shown page
+type xlsxMerge struct {
+	Rowspan, Colspan int
+	Value            string
+}
+
+// xlsxview read-only workbook view, one tab per sheet
+func xlsxview(cwd string, w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Path
+	fp := filepath.Join(cwd, name)
+	if _, err := os.Stat(fp); err != nil {
+		http.Error(w, "404 page not found", 404)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := xlsxSheet(&buf, fp, name, r); err != nil {
+		buf.WriteString(extRenderError("xlsx", err))
+	}
+
+	pg := page{}
+	pg.Title = filepath.Base(name) + " - mkup"
+
+	// 階層メニュー Dirnests
+	rd := filepath.Dir(name)
+	MenuDir(rd, &pg)
+
+	renderPage(w, pg, buf.Bytes())
+	return
+}
+
+// xlsxSheet sheet tabs and the requested page of the selected sheet
+func xlsxSheet(buf *bytes.Buffer, fp, name string, r *http.Request) error {
+	f, err := excelize.OpenFile(fp)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return fmt.Errorf("no sheets")
+	}
+	sheet := r.FormValue("sheet")
+	if sheet == "" {
+		sheet = f.GetSheetName(f.GetActiveSheetIndex())
+	}
+	if idx, _ := f.GetSheetIndex(sheet); idx < 0 || sheet == "" {
+		sheet = sheets[0]
+	}
+	pageNo, _ := strconv.Atoi(r.FormValue("page"))
+	if pageNo < 1 {
+		pageNo = 1
+	}
+	from := (pageNo-1)*xlsxPageRows + 1
+	to := from + xlsxPageRows - 1
+
+	buf.WriteString("<ul class=\"sheet-tabs\">\n")
+	for _, s := range sheets {
+		cls := []string{}
+		if s == sheet {
+			cls = append(cls, "active")
+		}
+		if visible, _ := f.GetSheetVisible(s); !visible {
+			cls = append(cls, "hidden-sheet")
+		}
+		fmt.Fprintf(buf, "<li class=\"%s\"><a href=\"?%s\">%s</a></li>\n",
+			strings.Join(cls, " "), html.EscapeString(url.Values{"sheet": {s}}.Encode()), html.EscapeString(s))
+	}
+	buf.WriteString("</ul>\n")
+
+	// 表示するページの行だけ持つ
+	rows, err := f.Rows(sheet)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	shown := [][]string{}
+	total, cols := 0, 0
+	for rows.Next() {
+		total++
+		if total < from || total > to {
+			continue
+		}
+		row, err := rows.Columns()
+		if err != nil {
+			return err
+		}
+		shown = append(shown, row)
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	if err := rows.Error(); err != nil {
+		return err
+	}
+	if total == 0 {
+		buf.WriteString("<p class=\"data-pager\">empty sheet</p>\n")
+		return nil
+	}
+
+	// 結合セル (ページ外にはみ出す分は切り詰め)
+	merges := map[[2]int]xlsxMerge{}
+	covered := map[[2]int]bool{}
+	mcs, err := f.GetMergeCells(sheet)
+	if err != nil {
+		return err
+	}
+	for _, mc := range mcs {
+		c1, r1, err1 := excelize.CellNameToCoordinates(mc.GetStartAxis())
+		c2, r2, err2 := excelize.CellNameToCoordinates(mc.GetEndAxis())
+		if err1 != nil || err2 != nil || r2 < from || r1 > to {
+			continue
+		}
+		if r1 < from {
+			r1 = from
+		}
+		if r2 > to {
+			r2 = to
+		}
+		if c2 > cols {
+			cols = c2
+		}
+		for y := r1; y <= r2; y++ {
+			for x := c1; x <= c2; x++ {
+				covered[[2]int{y, x}] = true
+			}
+		}
+		merges[[2]int{r1, c1}] = xlsxMerge{r2 - r1 + 1, c2 - c1 + 1, mc.GetCellValue()}
+	}
+
+	params := url.Values{"sheet": {sheet}}
+	pager := ""
+	if total > xlsxPageRows {
+		pager = dataPager(name, params, pageNo, total, xlsxPageRows)
+	}
+	buf.WriteString(pager)
+	buf.WriteString("<div class=\"sheet\">\n<table>\n<thead>\n<tr><th></th>")
+	for x := 1; x <= cols; x++ {
+		col, _ := excelize.ColumnNumberToName(x)
+		fmt.Fprintf(buf, "<th>%s</th>", col)
+	}
+	buf.WriteString("</tr>\n</thead>\n<tbody>\n")
+	for i, row := range shown {
+		y := from + i
+		fmt.Fprintf(buf, "<tr><th>%d</th>", y)
+		for x := 1; x <= cols; x++ {
+			v := ""
+			if x <= len(row) {
+				v = row[x-1]
+			}
+			attr := ""
+			if m, ok := merges[[2]int{y, x}]; ok {
+				v = m.Value
+				attr = fmt.Sprintf(" rowspan=\"%d\" colspan=\"%d\"", m.Rowspan, m.Colspan)
+			} else if covered[[2]int{y, x}] {
+				continue
+			}
+			if _, err := strconv.ParseFloat(strings.NewReplacer(",", "", "%", "").Replace(strings.TrimSpace(v)), 64); err == nil {
+				attr += " class=\"num\""
+			}
+			fmt.Fprintf(buf, "<td%s>%s</td>", attr, strings.Replace(html.EscapeString(v), "\n", "<br>", -1))
+		}
+		buf.WriteString("</tr>\n")
+	}
+	buf.WriteString("</tbody>\n</table>\n</div>\n")
+	buf.WriteString(pager)
+	return nil
+}